@@ -0,0 +1,203 @@
+package splitter
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// EnclosureMatcher is the interface for dynamic enclosures (see Enclosure.Matcher) - where the end of the enclosure
+// depends on what was found at the start (e.g. heredocs, PostgreSQL dollar-quoting, raw strings or fenced code)
+type EnclosureMatcher interface {
+	// MatchStart is called when the Start rune of the enclosure is encountered (at byte position `pos` in `str`) - it
+	// returns the length (in bytes) of the start found and the end sequence that terminates the enclosure
+	//
+	// a zero length (or empty end) indicates that the enclosure does not start at the position
+	//
+	// the `from` and `to` args are the byte positions between which the string may be examined - when streaming (see
+	// NewSplitFunc), `to` may only be the end of the input read so far, so a negative length should be returned if whether
+	// the enclosure starts at the position cannot be determined without examining beyond `to` (the start is then matched
+	// again once more input has been read - or, at the end of the input, a negative length is the same as a zero length)
+	MatchStart(str string, from int, pos int, to int) (n int, end string)
+}
+
+// EnclosureMatcherFunc is an adapter to allow the use of an ordinary function as an EnclosureMatcher
+type EnclosureMatcherFunc func(str string, from int, pos int, to int) (n int, end string)
+
+// MatchStart calls f(str, from, pos, to)
+func (f EnclosureMatcherFunc) MatchStart(str string, from int, pos int, to int) (n int, end string) {
+	return f(str, from, pos, to)
+}
+
+// matchHereDoc matches a heredoc start - `<<TAG` (or `<<-TAG`, `<<'TAG'` or `<<"TAG"`) - the end being the tag on a line
+// of its own
+func matchHereDoc(str string, from int, pos int, to int) (int, string) {
+	i := pos + 2
+	if i > to {
+		return -1, ""
+	} else if str[pos+1] != '<' || (pos > from && str[pos-1] == '<') {
+		return 0, ""
+	}
+	if i < to && str[i] == '-' {
+		i++
+	}
+	var quote byte
+	if i < to && (str[i] == '\'' || str[i] == '"') {
+		quote = str[i]
+		i++
+	}
+	tag, n := identAt(str, i, to)
+	if n == 0 && i >= to {
+		return -1, ""
+	} else if n == 0 {
+		return 0, ""
+	}
+	i += n
+	if quote != 0 {
+		if i >= to {
+			return -1, ""
+		} else if str[i] != quote {
+			return 0, ""
+		}
+		i++
+	}
+	return i - pos, tag
+}
+
+// hereDocMatcher is the matcher for heredocs - the start of a heredoc (e.g. `<<EOF`) is not itself enclosed, the body of
+// the heredoc starts at the end of the line on which the start was found (so the rest of that line is still split) and is
+// ended by a line that is just the tag (which, for a start of `<<-TAG`, may be preceded by tabs)
+type hereDocMatcher struct {
+	stripTabs bool
+}
+
+func (m hereDocMatcher) MatchStart(str string, from int, pos int, to int) (int, string) {
+	return matchHereDoc(str, from, pos, to)
+}
+
+// body returns the enclosure for the body of a heredoc whose start has been matched - the body starting with the newline
+// at the end of the line on which the start was found
+func (m hereDocMatcher) body(enc *Enclosure) *Enclosure {
+	enc.Matcher = hereDocMatcher{stripTabs: strings.HasPrefix(enc.StartSeq, "<<-")}
+	enc.Start, enc.StartSeq = '\n', "\n"
+	return enc
+}
+
+// endsAt returns the length (in bytes) of the end of a heredoc body found at the position - the tag on a line of its own
+// (zero if not found, or negative if it cannot be determined without examining beyond `to`)
+func (m hereDocMatcher) endsAt(str string, pos int, to int, tag string) int {
+	if pos == 0 || str[pos-1] != '\n' {
+		return 0
+	}
+	i := pos
+	for m.stripTabs && i < to && str[i] == '\t' {
+		i++
+	}
+	if i+len(tag) > to && strings.HasPrefix(tag, str[i:to]) {
+		return -1
+	} else if !strings.HasPrefix(str[i:to], tag) {
+		return 0
+	}
+	i += len(tag)
+	if i < to && str[i] != '\n' && str[i] != '\r' {
+		return 0
+	}
+	return i - pos
+}
+
+// pendingHereDoc is a heredoc whose start has been found but whose body has not yet started
+type pendingHereDoc struct {
+	enc     *Enclosure
+	runePos int
+}
+
+// matchDollarQuote matches a dollar-quoting start - `$TAG$` (or `$$`) - the end being the same as the start
+func matchDollarQuote(str string, from int, pos int, to int) (int, string) {
+	if r, _ := utf8.DecodeLastRuneInString(str[from:pos]); pos > from && isWordRune(r) {
+		// the `$` is part of an identifier (e.g. `foo$a`)...
+		return 0, ""
+	}
+	i := pos + 1
+	if i >= to {
+		return -1, ""
+	} else if r, _ := utf8.DecodeRuneInString(str[i:to]); unicode.IsDigit(r) {
+		// positional parameters (e.g. `$1`) are not dollar-quoting...
+		return 0, ""
+	}
+	_, n := identAt(str, i, to)
+	i += n
+	if i >= to {
+		return -1, ""
+	} else if str[i] != '$' {
+		return 0, ""
+	}
+	return i + 1 - pos, str[pos : i+1]
+}
+
+// matchRawString matches a raw string start - `r"` (or `r#"`, `r##"` etc.) - the end being `"` followed by the same number of `#`
+func matchRawString(str string, from int, pos int, to int) (int, string) {
+	if r, _ := utf8.DecodeLastRuneInString(str[from:pos]); pos > from && isWordRune(r) {
+		// the `r` is part of an identifier...
+		return 0, ""
+	}
+	i := pos + 1
+	for i < to && str[i] == '#' {
+		i++
+	}
+	if i >= to {
+		return -1, ""
+	} else if str[i] != '"' {
+		return 0, ""
+	}
+	return i + 1 - pos, `"` + str[pos+1:i]
+}
+
+// matchBacktickFence matches a run of backticks - the end being a run of exactly the same number of backticks
+func matchBacktickFence(str string, from int, pos int, to int) (int, string) {
+	i := pos
+	for i < to && str[i] == '`' {
+		i++
+	}
+	return i - pos, str[pos:i]
+}
+
+// backtickFenceMatcher is the matcher for backtick fences - a fence is only ended by a run of exactly the same number of
+// backticks (i.e. neither preceded nor followed by another backtick)
+type backtickFenceMatcher struct{}
+
+func (m backtickFenceMatcher) MatchStart(str string, from int, pos int, to int) (int, string) {
+	return matchBacktickFence(str, from, pos, to)
+}
+
+func (m backtickFenceMatcher) endsAt(str string, pos int, to int, end string) int {
+	if pos > 0 && str[pos-1] == '`' {
+		return 0
+	} else if pos+len(end) > to && strings.HasPrefix(end, str[pos:to]) {
+		return -1
+	} else if !strings.HasPrefix(str[pos:to], end) {
+		return 0
+	} else if i := pos + len(end); i < to && str[i] == '`' {
+		return 0
+	}
+	return len(end)
+}
+
+// endMatcher is implemented by the matchers of dynamic enclosures whose end is not simply found by the end sequence - the
+// length of the end found at the position is returned (zero if not found, or negative if it cannot be determined without
+// examining beyond `to`)
+type endMatcher interface {
+	endsAt(str string, pos int, to int, end string) int
+}
+
+// identAt returns the identifier (letters, digits and underscores) found at the position and its length (in bytes)
+func identAt(str string, pos int, to int) (string, int) {
+	i := pos
+	for i < to {
+		r, size := utf8.DecodeRuneInString(str[i:to])
+		if !isWordRune(r) {
+			break
+		}
+		i += size
+	}
+	return str[pos:i], i - pos
+}
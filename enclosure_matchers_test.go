@@ -0,0 +1,198 @@
+package splitter
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestEnclosureMatchers(t *testing.T) {
+	testCases := []struct {
+		matcher   EnclosureMatcherFunc
+		str       string
+		pos       int
+		expectN   int
+		expectEnd string
+	}{
+		{matchHereDoc, `<<EOF`, 0, 5, "EOF"},
+		{matchHereDoc, `cat <<END_1 x`, 4, 7, "END_1"},
+		{matchHereDoc, `<<'EOF'`, 0, 7, "EOF"},
+		{matchHereDoc, `<<"EOF"`, 0, 7, "EOF"},
+		{matchHereDoc, `<<-EOF`, 0, 6, "EOF"},
+		{matchHereDoc, `<<-'EOF'`, 0, 8, "EOF"},
+		{matchHereDoc, `<<'EOF`, 0, -1, ""},
+		{matchHereDoc, `<<`, 0, -1, ""},
+		{matchHereDoc, `<`, 0, -1, ""},
+		{matchHereDoc, `<a`, 0, 0, ""},
+		{matchHereDoc, `<<'EOF-`, 0, 0, ""},
+		{matchHereDoc, `<<-`, 0, -1, ""},
+		{matchHereDoc, `<< EOF`, 0, 0, ""},
+		{matchHereDoc, `<<<a`, 0, 0, ""},
+		{matchHereDoc, `<<<a`, 1, 0, ""},
+		{matchDollarQuote, `$$`, 0, 2, "$$"},
+		{matchDollarQuote, `$body$ x`, 0, 6, "$body$"},
+		{matchDollarQuote, `$1$`, 0, 0, ""},
+		{matchDollarQuote, `foo$a$`, 3, 0, ""},
+		{matchDollarQuote, `$a`, 0, -1, ""},
+		{matchDollarQuote, `$`, 0, -1, ""},
+		{matchDollarQuote, `$a-`, 0, 0, ""},
+		{matchRawString, `r"`, 0, 2, `"`},
+		{matchRawString, `r##"a"#"##`, 0, 4, `"##`},
+		{matchRawString, `x = r#"a"#`, 4, 3, `"#`},
+		{matchRawString, `bar"a"`, 2, 0, ""},
+		{matchRawString, `r#`, 0, -1, ""},
+		{matchRawString, `r`, 0, -1, ""},
+		{matchRawString, `r#x`, 0, 0, ""},
+		{matchBacktickFence, "`a`", 0, 1, "`"},
+		{matchBacktickFence, "```go", 0, 3, "```"},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			n, end := tc.matcher.MatchStart(tc.str, 0, tc.pos, len(tc.str))
+			require.Equal(t, tc.expectN, n)
+			if n > 0 {
+				require.Equal(t, tc.expectEnd, end)
+			}
+		})
+	}
+
+	// only what follows the start of the context is examined...
+	n, end := matchRawString(`xr"a"`, 1, 1, 5)
+	require.Equal(t, 2, n)
+	require.Equal(t, `"`, end)
+}
+
+func TestSplitter_Split_HereDocs(t *testing.T) {
+	testCases := []struct {
+		separator rune
+		str       string
+		expect    []string
+	}{
+		// the rest of the line on which the heredoc starts is still split...
+		{';', "cat <<EOF; echo x\nbody;\nEOF\n; ls", []string{"cat <<EOF", " echo x\nbody;\nEOF\n", " ls"}},
+		{'\n', "cat <<EOF\nEOF\nls", []string{"cat <<EOF\nEOF", "ls"}},
+		// the end must be the tag on a line of its own...
+		{'\n', "cat <<EOF\nEOFX\n EOF\nEOF\nls", []string{"cat <<EOF\nEOFX\n EOF\nEOF", "ls"}},
+		{'\n', "cat <<EOF\nx\nEOF", []string{"cat <<EOF\nx\nEOF"}},
+		{'\n', "cat <<EOF\r\nx\r\nEOF\r\nls", []string{"cat <<EOF\r\nx\r\nEOF\r", "ls"}},
+		// tabs before the end tag are only allowed with `<<-`...
+		{'\n', "cat <<-EOF\n\tx\n\tEOF\nls", []string{"cat <<-EOF\n\tx\n\tEOF", "ls"}},
+		{'\n', "cat <<EOF\n\tEOF\nEOF\nls", []string{"cat <<EOF\n\tEOF\nEOF", "ls"}},
+		// multiple heredocs on the same line...
+		{'\n', "cat <<A <<B\na\nA\nb\nB\nls", []string{"cat <<A <<B\na\nA\nb\nB", "ls"}},
+		// here strings are not heredocs...
+		{'\n', "cat <<<x\nls", []string{"cat <<<x", "ls"}},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			s, err := NewSplitter(tc.separator, HereDocs, DoubleQuotes)
+			require.NoError(t, err)
+			parts, err := s.Split(tc.str)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, parts)
+		})
+	}
+
+	s, err := NewSplitter(';', HereDocs)
+	require.NoError(t, err)
+	spc := &subPartsCapture{}
+	_, err = s.Split("cat <<EOF; echo x\nbody;\nEOF\n", spc, StripQuotes)
+	require.NoError(t, err)
+	subParts := spc.subParts[1]
+	require.Equal(t, 3, len(subParts))
+	require.Equal(t, "\nbody;\nEOF", subParts[1].String())
+	require.Equal(t, "body;\n", subParts[1].UnEscaped())
+
+	_, err = s.Split("cat <<EOF; x")
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `<`, 4), err.Error())
+	_, err = s.Split("cat <<EOF\nx\nEOFX")
+	require.Error(t, err)
+	errs := s.Validate("cat <<EOF; x")
+	require.Equal(t, 1, len(errs))
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `<`, 4), errs[0].Error())
+	require.Empty(t, s.Validate("cat <<EOF\nx\nEOF"))
+
+	ws, err := NewWhitespaceSplitter(HereDocs)
+	require.NoError(t, err)
+	parts, err := ws.Split("cat <<EOF \nx y\nEOF\n ls")
+	require.NoError(t, err)
+	require.Equal(t, []string{"cat", "<<EOF", "\nx y\nEOF", "ls"}, parts)
+}
+
+func TestSplitter_Split_DynamicEnclosures(t *testing.T) {
+	testCases := []struct {
+		separator rune
+		enc       *Enclosure
+		str       string
+		expect    []string
+	}{
+		{';', DollarQuotes, `SELECT 1; CREATE FUNCTION f() AS $$ BEGIN; END; $$; SELECT 2`, []string{`SELECT 1`, ` CREATE FUNCTION f() AS $$ BEGIN; END; $$`, ` SELECT 2`}},
+		{';', DollarQuotes, `a; $x$ $$; $y$; $x$; b`, []string{`a`, ` $x$ $$; $y$; $x$`, ` b`}},
+		{';', DollarQuotes, `a; $1; $2`, []string{`a`, ` $1`, ` $2`}},
+		{';', DollarQuotes, `select foo$a$;b$a$`, []string{`select foo$a$`, `b$a$`}},
+		{';', DollarQuotes, `a;$a$;$a$`, []string{`a`, `$a$;$a$`}},
+		{'\n', HereDocs, "echo a\ncat <<EOF\nb\nc\nEOF\necho d", []string{"echo a", "cat <<EOF\nb\nc\nEOF", "echo d"}},
+		{'\n', HereDocs, "cat <<'X'\nEOF\nX", []string{"cat <<'X'\nEOF\nX"}},
+		{',', RawStrings, `a, r#"b, "c""#, r"d,", bar`, []string{`a`, ` r#"b, "c""#`, ` r"d,"`, ` bar`}},
+		{' ', BacktickFences, "a ``b ` c`` ```d `` e``` f", []string{"a", "``b ` c``", "```d `` e```", "f"}},
+		{' ', BacktickFences, "a ``b ``` c`` d", []string{"a", "``b ``` c``", "d"}},
+		{' ', BacktickFences, "a ``b```` c`` d", []string{"a", "``b```` c``", "d"}},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			s, err := NewSplitter(tc.separator, tc.enc)
+			require.NoError(t, err)
+			parts, err := s.Split(tc.str)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, parts)
+		})
+	}
+
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis, DollarQuotes, RawStrings)
+	require.NoError(t, err)
+	spc := &subPartsCapture{}
+	parts, err := s.Split(`$q$a,b$q$ (r"c)") "$$"`, spc, StripQuotes)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a,b (r"c)") $$`}, parts)
+	subParts := spc.subParts[0]
+	require.Equal(t, 5, len(subParts))
+	require.Equal(t, Quotes, subParts[0].Type())
+	require.Equal(t, `$q$a,b$q$`, subParts[0].String())
+	require.Equal(t, `a,b`, subParts[0].UnEscaped())
+	require.Equal(t, '$', subParts[0].StartRune())
+	require.Equal(t, '$', subParts[0].EndRune())
+	require.Equal(t, Brackets, subParts[2].Type())
+	require.Equal(t, `(r"c)")`, subParts[2].String())
+
+	_, err = s.Split(`a, $x$ b $y$`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `$`, 3), err.Error())
+	errs := s.Validate(`a, r#"b"`)
+	require.Equal(t, 1, len(errs))
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `r`, 3), errs[0].Error())
+
+	// a custom matcher - `%{n}` ... `%{n}`...
+	custom := &Enclosure{
+		Start:   '%',
+		IsQuote: true,
+		Matcher: EnclosureMatcherFunc(func(str string, from int, pos int, to int) (int, string) {
+			if pos+3 < to && str[pos+1] == '{' && str[pos+3] == '}' {
+				return 4, str[pos : pos+4]
+			}
+			return 0, ""
+		}),
+	}
+	s, err = NewSplitter(',', custom, Parenthesis)
+	require.NoError(t, err)
+	parts, err = s.Split(`a,%{1}b,%{2},%{1},(%),c`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `%{1}b,%{2},%{1}`, `(%)`, `c`}, parts)
+
+	_, err = NewSplitter(',', HereDocs, LtGtAngleBrackets)
+	require.Error(t, err)
+	_, err = NewSplitter(',', BacktickFences, SingleInvertedQuotes)
+	require.Error(t, err)
+	_, err = NewSplitter(',', HereDocs, HtmlComments, DollarQuotes, RawStrings, BacktickFences)
+	require.NoError(t, err)
+}
@@ -1,6 +1,11 @@
 package splitter
 
-import "errors"
+import (
+	"errors"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
 
 // Enclosure is used when creating a splitter to denote what 'enclosures' (e.g. quotes or brackets)
 // are to be considered when slitting.
@@ -20,20 +25,257 @@ type Enclosure struct {
 	Escapable bool
 	// the prefix escape rune (only for IsQuote & and used with Escapable)
 	Escape rune
+	// the starting sequence for the enclosure - for multi-rune starts (e.g. `/*`), if set, Start is not used
+	StartSeq string
+	// the ending sequence for the enclosure - for multi-rune ends (e.g. `*/`), if set, End is not used
+	EndSeq string
+	// whether the enclosure is a comment (comments, like quotes, cannot contain other enclosures - see also Splitter.SetCommentPolicy)
+	IsComment bool
+	// whether the comment is a line comment - i.e. runs from the start to the end of the line (or end of string) and End is not used (only for IsComment)
+	IsLineComment bool
+	// the matcher for a dynamic enclosure - whose end depends on what was found at the start (e.g. heredocs) - if set, Start
+	// is the first rune of any start and StartSeq, End and EndSeq are not used
+	Matcher EnclosureMatcher
+	// the prefix that selects this variant of the enclosure (e.g. `E` for PostgreSQL escape strings `E'...'`) - the prefix must
+	// immediately precede the start (and not itself follow a letter, digit or underscore) and is matched case-insensitively
+	Prefix string
+	// whether the enclosure is opaque - i.e. other enclosures within it are not considered (quotes and comments are always opaque)
+	Opaque bool
+	// the enclosures that may be nested within the enclosure - for an opaque enclosure, only these enclosures are considered
+	// within it, otherwise any other enclosure found within it is a nesting violation (if empty, there is no restriction on
+	// nesting within non-opaque enclosures)
+	AllowedChildren []*Enclosure
+	// the maximum nesting depth at which the enclosure may be opened - e.g. 1 means that the enclosure may only be opened
+	// when not within any other enclosure (zero for no maximum depth)
+	MaxDepth int
 }
 
 func (e *Enclosure) clone() Enclosure {
 	return Enclosure{
-		Start:     e.Start,
-		End:       e.End,
-		IsQuote:   e.IsQuote,
-		Escapable: e.Escapable,
-		Escape:    e.Escape,
+		Start:           e.Start,
+		End:             e.End,
+		IsQuote:         e.IsQuote,
+		Escapable:       e.Escapable,
+		Escape:          e.Escape,
+		StartSeq:        e.StartSeq,
+		EndSeq:          e.EndSeq,
+		IsComment:       e.IsComment,
+		IsLineComment:   e.IsLineComment,
+		Matcher:         e.Matcher,
+		Prefix:          e.Prefix,
+		Opaque:          e.Opaque,
+		AllowedChildren: e.AllowedChildren,
+		MaxDepth:        e.MaxDepth,
+	}
+}
+
+// copy returns a clone of the enclosure (so that the splitter's own enclosures are not exposed, e.g. by errors)
+func (e *Enclosure) copy() *Enclosure {
+	c := e.clone()
+	return &c
+}
+
+// isOpaque returns whether other enclosures are not considered within the enclosure (i.e. quotes, comments and opaque brackets)
+func (e *Enclosure) isOpaque() bool {
+	return e.IsQuote || e.IsComment || e.Opaque
+}
+
+// allows returns whether the child enclosure may be nested within the enclosure
+func (e *Enclosure) allows(child *Enclosure) bool {
+	if len(e.AllowedChildren) == 0 {
+		return !e.isOpaque()
+	}
+	for _, ac := range e.AllowedChildren {
+		if ac != nil && ac.isSame(child) {
+			return true
+		}
+	}
+	return false
+}
+
+// isSame returns whether the enclosure is the same as the other enclosure - i.e. has the same start (or, for
+// dynamic enclosures, the same start rune)
+func (e *Enclosure) isSame(other *Enclosure) bool {
+	if e.Matcher != nil || other.Matcher != nil {
+		return e.Matcher != nil && other.Matcher != nil && e.Start == other.Start && strings.EqualFold(e.Prefix, other.Prefix)
+	}
+	return e.sameStart(other)
+}
+
+// startSeq returns the start sequence of the enclosure
+func (e *Enclosure) startSeq() string {
+	if e.StartSeq != "" {
+		return e.StartSeq
 	}
+	return string(e.Start)
+}
+
+// endSeq returns the end sequence of the enclosure
+func (e *Enclosure) endSeq() string {
+	if e.EndSeq != "" {
+		return e.EndSeq
+	}
+	return string(e.End)
+}
+
+// startRune returns the first rune of the start sequence
+func (e *Enclosure) startRune() rune {
+	if e.StartSeq != "" {
+		r, _ := utf8.DecodeRuneInString(e.StartSeq)
+		return r
+	}
+	return e.Start
+}
+
+// endRune returns the first rune of the end sequence
+func (e *Enclosure) endRune() rune {
+	if e.EndSeq != "" {
+		r, _ := utf8.DecodeRuneInString(e.EndSeq)
+		return r
+	}
+	return e.End
+}
+
+// startLen returns the length (in bytes) of the start sequence (including any prefix)
+func (e *Enclosure) startLen() int {
+	if e.StartSeq != "" {
+		return len(e.Prefix) + len(e.StartSeq)
+	}
+	return len(e.Prefix) + utf8.RuneLen(e.Start)
+}
+
+// openRunes returns the runes with which the enclosure can start - the start rune or, for a prefixed enclosure, the
+// first rune of the prefix (in either case)
+func (e *Enclosure) openRunes() []rune {
+	if e.Prefix == "" {
+		return []rune{e.startRune()}
+	}
+	r, _ := utf8.DecodeRuneInString(e.Prefix)
+	result := []rune{r}
+	for fr := unicode.SimpleFold(r); fr != r; fr = unicode.SimpleFold(fr) {
+		result = append(result, fr)
+	}
+	return result
+}
+
+// sameStart returns whether the enclosure has the same start (and prefix) as the other enclosure
+func (e *Enclosure) sameStart(other *Enclosure) bool {
+	return strings.EqualFold(e.Prefix, other.Prefix) && e.startSeq() == other.startSeq()
+}
+
+// startsWith returns whether the start of the enclosure (with or without any prefix, which is matched case-insensitively)
+// starts with the text
+func (e *Enclosure) startsWith(s string) bool {
+	if start := e.Prefix + e.startSeq(); e.Prefix != "" && len(s) <= len(start) && strings.EqualFold(start[:len(s)], s) {
+		return true
+	}
+	return strings.HasPrefix(e.startSeq(), s)
+}
+
+// endLen returns the length (in bytes) of the end sequence
+func (e *Enclosure) endLen() int {
+	if e.EndSeq != "" {
+		return len(e.EndSeq)
+	}
+	return utf8.RuneLen(e.End)
+}
+
+// startsAt returns the length (in bytes) of the start sequence (including any prefix) if it is found at the position
+// in the string (or zero if not found)
+func (e *Enclosure) startsAt(str string, from int, pos int, to int) int {
+	p, ok := e.prefixAt(str, from, pos, to)
+	if !ok {
+		return 0
+	}
+	if n := seqAt(str, pos+p, to, e.Start, e.StartSeq); n > 0 {
+		return p + n
+	}
+	return 0
+}
+
+// prefixAt returns the length (in bytes) of the prefix and whether it is found at the position in the string (always
+// found if the enclosure has no prefix)
+func (e *Enclosure) prefixAt(str string, from int, pos int, to int) (int, bool) {
+	if e.Prefix == "" {
+		return 0, true
+	} else if r, _ := utf8.DecodeLastRuneInString(str[from:pos]); pos > from && isWordRune(r) {
+		// the prefix is the end of an identifier...
+		return 0, false
+	}
+	n := 0
+	for _, pr := range e.Prefix {
+		r, size := utf8.DecodeRuneInString(str[pos+n : to])
+		if size == 0 || !equalFoldRune(r, pr) {
+			return 0, false
+		}
+		n += size
+	}
+	return n, true
+}
+
+// startAt returns the length (in bytes) of the start if it is found at the position in the string (or zero if not found)
+// along with the enclosure - for a dynamic enclosure, the returned enclosure has the start and end sequences that were matched
+func (e *Enclosure) startAt(str string, from int, pos int, to int) (*Enclosure, int) {
+	if e.Matcher == nil {
+		return e, e.startsAt(str, from, pos, to)
+	}
+	p, ok := e.prefixAt(str, from, pos, to)
+	if !ok {
+		return e, 0
+	}
+	n, end := e.Matcher.MatchStart(str, from, pos+p, to)
+	if n < 0 {
+		return e, -1
+	} else if n == 0 || pos+p+n > to || end == "" {
+		return e, 0
+	}
+	result := e.copy()
+	// (copied - as, when streaming, the string shares the memory of a buffer that is later reused)...
+	result.StartSeq = strings.Clone(str[pos+p : pos+p+n])
+	result.EndSeq = strings.Clone(end)
+	result.End, _ = utf8.DecodeRuneInString(end)
+	if m, ok := e.Matcher.(hereDocMatcher); ok {
+		result = m.body(result)
+	}
+	return result, p + n
+}
+
+// endsAt returns the length (in bytes) of the end sequence if it is found at the position in the string (zero if not found,
+// or negative if only the start of the end sequence is found before `to`)
+func (e *Enclosure) endsAt(str string, pos int, to int) int {
+	if m, ok := e.Matcher.(endMatcher); ok {
+		return m.endsAt(str, pos, to, e.EndSeq)
+	} else if pos+len(e.EndSeq) > to && strings.HasPrefix(e.EndSeq, str[pos:to]) {
+		return -1
+	}
+	return seqAt(str, pos, to, e.End, e.EndSeq)
+}
+
+// isHereDoc returns whether the enclosure is a heredoc (whose body starts at the end of the line the start is found on)
+func (e *Enclosure) isHereDoc() bool {
+	_, ok := e.Matcher.(hereDocMatcher)
+	return ok
+}
+
+func seqAt(str string, pos int, to int, r rune, seq string) int {
+	if seq == "" {
+		if sr, size := utf8.DecodeRuneInString(str[pos:to]); size > 0 && sr == r {
+			return size
+		}
+		return 0
+	} else if strings.HasPrefix(str[pos:to], seq) {
+		return len(seq)
+	}
+	return 0
+}
+
+// hasEnd returns whether the enclosure has a fixed end (i.e. is not a line comment or dynamic enclosure)
+func (e *Enclosure) hasEnd() bool {
+	return !e.IsLineComment && e.Matcher == nil
 }
 
 func (e *Enclosure) isDoubleEscaping() bool {
-	return e.IsQuote && e.Escapable && e.End == e.Escape
+	return e.IsQuote && e.Escapable && e.endRune() == e.Escape
 }
 
 func (e *Enclosure) isEscapable() bool {
@@ -41,7 +283,7 @@ func (e *Enclosure) isEscapable() bool {
 }
 
 func (e *Enclosure) isBracketEscapable() bool {
-	return !e.IsQuote && e.Escapable
+	return !e.isOpaque() && e.Escapable
 }
 
 // MakeEscapable makes an escapable copy of an enclosure
@@ -50,15 +292,24 @@ func (e *Enclosure) isBracketEscapable() bool {
 // and the escape rune matches either the start or end rune
 // (because brackets cannot be double-escaped - as this would prevent nested brackets)
 func MakeEscapable(enc *Enclosure, esc rune) (*Enclosure, error) {
-	if !enc.IsQuote && (esc == enc.Start || esc == enc.End) {
+	if !enc.isOpaque() && (esc == enc.startRune() || esc == enc.endRune()) {
 		return nil, errors.New("bracket enclosures cannot be double-escaped")
 	}
 	return &Enclosure{
-		Start:     enc.Start,
-		End:       enc.End,
-		IsQuote:   enc.IsQuote,
-		Escapable: true,
-		Escape:    esc,
+		Start:           enc.Start,
+		End:             enc.End,
+		IsQuote:         enc.IsQuote,
+		Escapable:       true,
+		Escape:          esc,
+		StartSeq:        enc.StartSeq,
+		EndSeq:          enc.EndSeq,
+		IsComment:       enc.IsComment,
+		IsLineComment:   enc.IsLineComment,
+		Matcher:         enc.Matcher,
+		Prefix:          enc.Prefix,
+		Opaque:          enc.Opaque,
+		AllowedChildren: enc.AllowedChildren,
+		MaxDepth:        enc.MaxDepth,
 	}, nil
 }
 
@@ -145,6 +396,25 @@ var (
 	MediumOrnamentalCurlyBrackets             = _MediumOrnamentalCurlyBrackets
 	HeavyOrnamentalPointingAngleQuotes        = _HeavyOrnamentalPointingAngleQuotes
 	HeavyOrnamentalPointingAngleBrackets      = _HeavyOrnamentalPointingAngleBrackets
+	TripleDoubleQuotes                        = _TripleDoubleQuotes
+	TripleDoubleQuotesBackSlashEscaped        = MustMakeEscapable(_TripleDoubleQuotes, escBackslash)
+	TripleSingleQuotes                        = _TripleSingleQuotes
+	TripleSingleQuotesBackSlashEscaped        = MustMakeEscapable(_TripleSingleQuotes, escBackslash)
+	DoubleCurlyBrackets                       = _DoubleCurlyBrackets
+	HashLineComments                          = _HashLineComments
+	DoubleSlashLineComments                   = _DoubleSlashLineComments
+	DoubleDashLineComments                    = _DoubleDashLineComments
+	BlockComments                             = _BlockComments
+	HtmlComments                              = _HtmlComments
+	HereDocs                                  = _HereDocs
+	DollarQuotes                              = _DollarQuotes
+	RawStrings                                = _RawStrings
+	BacktickFences                            = _BacktickFences
+	EscapePrefixedSingleQuotes                = _EscapePrefixedSingleQuotes
+	RawPrefixedSingleQuotes                   = _RawPrefixedSingleQuotes
+	RawPrefixedDoubleQuotes                   = _RawPrefixedDoubleQuotes
+	BytesPrefixedSingleQuotes                 = _BytesPrefixedSingleQuotes
+	BytesPrefixedDoubleQuotes                 = _BytesPrefixedDoubleQuotes
 )
 
 var (
@@ -412,4 +682,114 @@ var (
 		Start: '\u2770',
 		End:   '\u2771',
 	}
+	_TripleDoubleQuotes = &Enclosure{
+		Start:    '"',
+		End:      '"',
+		IsQuote:  true,
+		StartSeq: `"""`,
+		EndSeq:   `"""`,
+	}
+	_TripleSingleQuotes = &Enclosure{
+		Start:    '\'',
+		End:      '\'',
+		IsQuote:  true,
+		StartSeq: `'''`,
+		EndSeq:   `'''`,
+	}
+	_DoubleCurlyBrackets = &Enclosure{
+		Start:    '{',
+		End:      '}',
+		StartSeq: "{{",
+		EndSeq:   "}}",
+	}
+	_HashLineComments = &Enclosure{
+		Start:         '#',
+		End:           '\n',
+		IsComment:     true,
+		IsLineComment: true,
+	}
+	_DoubleSlashLineComments = &Enclosure{
+		Start:         '/',
+		End:           '\n',
+		StartSeq:      "//",
+		IsComment:     true,
+		IsLineComment: true,
+	}
+	_DoubleDashLineComments = &Enclosure{
+		Start:         '-',
+		End:           '\n',
+		StartSeq:      "--",
+		IsComment:     true,
+		IsLineComment: true,
+	}
+	_BlockComments = &Enclosure{
+		Start:     '/',
+		End:       '*',
+		StartSeq:  "/*",
+		EndSeq:    "*/",
+		IsComment: true,
+	}
+	_HtmlComments = &Enclosure{
+		Start:     '<',
+		End:       '-',
+		StartSeq:  "<!--",
+		EndSeq:    "-->",
+		IsComment: true,
+	}
+	_HereDocs = &Enclosure{
+		Start:   '<',
+		IsQuote: true,
+		Matcher: hereDocMatcher{},
+	}
+	_DollarQuotes = &Enclosure{
+		Start:   '$',
+		IsQuote: true,
+		Matcher: EnclosureMatcherFunc(matchDollarQuote),
+	}
+	_RawStrings = &Enclosure{
+		Start:   'r',
+		IsQuote: true,
+		Matcher: EnclosureMatcherFunc(matchRawString),
+	}
+	_BacktickFences = &Enclosure{
+		Start:   '`',
+		IsQuote: true,
+		Matcher: backtickFenceMatcher{},
+	}
+	_EscapePrefixedSingleQuotes = &Enclosure{
+		Start:     '\'',
+		End:       '\'',
+		IsQuote:   true,
+		Escapable: true,
+		Escape:    escBackslash,
+		Prefix:    "E",
+	}
+	_RawPrefixedSingleQuotes = &Enclosure{
+		Start:   '\'',
+		End:     '\'',
+		IsQuote: true,
+		Prefix:  "r",
+	}
+	_RawPrefixedDoubleQuotes = &Enclosure{
+		Start:   '"',
+		End:     '"',
+		IsQuote: true,
+		Prefix:  "r",
+	}
+	_BytesPrefixedSingleQuotes = &Enclosure{
+		Start:     '\'',
+		End:       '\'',
+		IsQuote:   true,
+		Escapable: true,
+		Escape:    escBackslash,
+		Prefix:    "b",
+	}
+	_BytesPrefixedDoubleQuotes = &Enclosure{
+		Start:     '"',
+		End:       '"',
+		IsQuote:   true,
+		Escapable: true,
+		Escape:    escBackslash,
+		Prefix:    "b",
+	}
 )
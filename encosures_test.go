@@ -77,14 +77,35 @@ var testEnclosures = map[string]*Enclosure{
 	"MediumOrnamentalCurlyBrackets":             MediumOrnamentalCurlyBrackets,
 	"HeavyOrnamentalPointingAngleQuotes":        HeavyOrnamentalPointingAngleQuotes,
 	"HeavyOrnamentalPointingAngleBrackets":      HeavyOrnamentalPointingAngleBrackets,
+	"TripleDoubleQuotes":                        TripleDoubleQuotes,
+	"TripleDoubleQuotesBackSlashEscaped":        TripleDoubleQuotesBackSlashEscaped,
+	"TripleSingleQuotes":                        TripleSingleQuotes,
+	"TripleSingleQuotesBackSlashEscaped":        TripleSingleQuotesBackSlashEscaped,
+	"DoubleCurlyBrackets":                       DoubleCurlyBrackets,
+	"HashLineComments":                          HashLineComments,
+	"DoubleSlashLineComments":                   DoubleSlashLineComments,
+	"DoubleDashLineComments":                    DoubleDashLineComments,
+	"BlockComments":                             BlockComments,
+	"HtmlComments":                              HtmlComments,
+	"HereDocs":                                  HereDocs,
+	"DollarQuotes":                              DollarQuotes,
+	"RawStrings":                                RawStrings,
+	"BacktickFences":                            BacktickFences,
+	"EscapePrefixedSingleQuotes":                EscapePrefixedSingleQuotes,
+	"RawPrefixedSingleQuotes":                   RawPrefixedSingleQuotes,
+	"RawPrefixedDoubleQuotes":                   RawPrefixedDoubleQuotes,
+	"BytesPrefixedSingleQuotes":                 BytesPrefixedSingleQuotes,
+	"BytesPrefixedDoubleQuotes":                 BytesPrefixedDoubleQuotes,
 }
 
 func TestEnclosures(t *testing.T) {
 	for name, enc := range testEnclosures {
 		t.Run(fmt.Sprintf("%s", name), func(t *testing.T) {
 			require.NotEqual(t, rune(0), enc.Start)
-			require.NotEqual(t, rune(0), enc.End)
-			if strings.Contains(name, "Quote") {
+			if enc.Matcher == nil {
+				require.NotEqual(t, rune(0), enc.End)
+			}
+			if strings.Contains(name, "Quote") || enc.Matcher != nil {
 				require.True(t, enc.IsQuote)
 				require.Equal(t, enc.Escape == rune(0), !enc.Escapable)
 				if enc.Escapable {
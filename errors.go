@@ -13,6 +13,10 @@ const (
 	Unclosed
 	OptionFail
 	Wrapped
+	NotEnclosed
+	Mismatched
+	CommentFound
+	NestingViolation
 )
 
 // SplittingError is the error type always returned from Splitter.Split
@@ -45,8 +49,12 @@ func newSplittingError(t SplittingErrorType, pos int, r rune, enc *Enclosure) Sp
 }
 
 const (
-	unopenedFmt = "unopened '%s' at position %d"
-	unclosedFmt = "unclosed '%s' at position %d"
+	unopenedFmt    = "unopened '%s' at position %d"
+	unclosedFmt    = "unclosed '%s' at position %d"
+	notEnclosedFmt = "not enclosed at position %d"
+	mismatchedFmt  = "mismatched '%s' at position %d"
+	commentFmt     = "comment at position %d"
+	nestingFmt     = "nesting violation '%s' at position %d"
 )
 
 func (e *splittingError) Error() string {
@@ -54,6 +62,14 @@ func (e *splittingError) Error() string {
 		return fmt.Sprintf(unopenedFmt, string(e.rune), e.position)
 	} else if e.errorType == Unclosed {
 		return fmt.Sprintf(unclosedFmt, string(e.rune), e.position)
+	} else if e.errorType == Mismatched {
+		return fmt.Sprintf(mismatchedFmt, string(e.rune), e.position)
+	} else if e.errorType == CommentFound {
+		return fmt.Sprintf(commentFmt, e.position)
+	} else if e.errorType == NestingViolation {
+		return fmt.Sprintf(nestingFmt, string(e.rune), e.position)
+	} else if e.errorType == NotEnclosed {
+		return fmt.Sprintf(notEnclosedFmt, e.position)
 	} else if e.wrapped != nil {
 		return e.wrapped.Error()
 	}
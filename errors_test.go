@@ -39,6 +39,18 @@ func TestNewSplittingError(t *testing.T) {
 
 	err = newSplittingError(Unclosed, 16, ')', Parenthesis)
 	require.Equal(t, fmt.Sprintf(unclosedFmt, ")", 16), err.Error())
+
+	err = newSplittingError(NotEnclosed, 16, 'a', nil)
+	require.Equal(t, fmt.Sprintf(notEnclosedFmt, 16), err.Error())
+
+	err = newSplittingError(Mismatched, 16, ']', Parenthesis)
+	require.Equal(t, fmt.Sprintf(mismatchedFmt, "]", 16), err.Error())
+
+	err = newSplittingError(CommentFound, 16, '#', HashLineComments)
+	require.Equal(t, fmt.Sprintf(commentFmt, 16), err.Error())
+
+	err = newSplittingError(NestingViolation, 16, '(', Parenthesis)
+	require.Equal(t, fmt.Sprintf(nestingFmt, "(", 16), err.Error())
 }
 
 func TestSplittingError_DefaultMessage(t *testing.T) {
@@ -60,9 +72,10 @@ func TestNewOptionFailError(t *testing.T) {
 	require.Equal(t, 16, sErr.Position())
 
 	subPart := &subPart{
-		enc:      *Parenthesis,
-		openPos:  5,
-		closePos: 10,
+		enc:   Parenthesis,
+		start: 5,
+		end:   11,
+		ctx:   newSplitterContext("abcde(fghij)", &splitter{}, nil),
 	}
 	err = NewOptionFailError("whoops", 0, subPart)
 	require.Error(t, err)
@@ -0,0 +1,113 @@
+package splitter
+
+import (
+	"errors"
+	"fmt"
+)
+
+// LevelSplitter is a splitter that splits hierarchically - i.e. each part split by the first level splitter
+// is then split by the second level splitter (and so on)
+//
+// For example, a LevelSplitter composed of a ';' splitter, a ',' splitter and an '=' splitter would split...
+//
+//	str := `a=1,b=2;c=3`
+//
+// into two parts (`a=1,b=2` and `c=3`), each of those into parts (`a=1`, `b=2` and `c=3`), and each of those into parts (`a`, `1` etc.)
+type LevelSplitter interface {
+	// Split performs a hierarchical split on the supplied string - returns the split parts and any error encountered
+	//
+	// The default options of each level splitter are applied to the parts at that level - but note that the next
+	// level splitter always splits the original (i.e. not modified by options) part
+	//
+	// If an error is returned, it will always be of type SplittingError - and the error position is always relative to the supplied string
+	Split(s string) ([]*LevelPart, error)
+	// Levels returns the number of levels
+	Levels() int
+}
+
+// LevelPart is a split part returned by LevelSplitter.Split
+type LevelPart struct {
+	// Value is the split part (after any options for the level have been applied)
+	Value string
+	// Separator is the separator that terminated the part (empty string if terminated by the end)
+	Separator string
+	// Parts is the split parts of the next level (nil if this is the last level)
+	Parts []*LevelPart
+}
+
+// Values returns the values of the next level parts
+func (p *LevelPart) Values() []string {
+	if p.Parts == nil {
+		return nil
+	}
+	result := make([]string, len(p.Parts))
+	for i, pt := range p.Parts {
+		result[i] = pt.Value
+	}
+	return result
+}
+
+// NewLevelSplitter creates a new LevelSplitter from the supplied splitters (each splitter being a level)
+//
+// An error is returned if no levels are specified or any level is nil
+func NewLevelSplitter(levels ...Splitter) (LevelSplitter, error) {
+	if len(levels) == 0 {
+		return nil, errors.New("at least one level must be specified")
+	}
+	result := &levelSplitter{
+		levels: make([]*splitter, 0, len(levels)),
+	}
+	for i, l := range levels {
+		if s, ok := l.(*splitter); ok && s != nil {
+			result.levels = append(result.levels, s)
+		} else {
+			return nil, fmt.Errorf("invalid splitter (level[%d])", i+1)
+		}
+	}
+	return result, nil
+}
+
+// MustCreateLevelSplitter is the same as NewLevelSplitter, except that it panics in case of error
+func MustCreateLevelSplitter(levels ...Splitter) LevelSplitter {
+	if s, err := NewLevelSplitter(levels...); err != nil {
+		panic(err)
+	} else {
+		return s
+	}
+}
+
+type levelSplitter struct {
+	levels []*splitter
+}
+
+func (l *levelSplitter) Split(str string) ([]*LevelPart, error) {
+	return l.split(str, 0, len(str), 0, 0)
+}
+
+func (l *levelSplitter) Levels() int {
+	return len(l.levels)
+}
+
+func (l *levelSplitter) split(str string, from int, to int, fromRune int, level int) ([]*LevelPart, error) {
+	s := l.levels[level]
+	ctx := newRangeSplitterContext(str, from, to, fromRune, s, s.defOptions)
+	ctx.separators = make([]string, 0, cap(ctx.captured))
+	ctx.spans = make([]span, 0, cap(ctx.captured))
+	parts, err := ctx.split()
+	if err != nil {
+		return nil, err
+	}
+	result := make([]*LevelPart, len(parts))
+	for i, pt := range parts {
+		result[i] = &LevelPart{
+			Value:     pt,
+			Separator: ctx.separators[i],
+		}
+		if level < len(l.levels)-1 {
+			if result[i].Parts, err = l.split(str, ctx.spans[i].start, ctx.spans[i].end, ctx.runePos(ctx.spans[i].start), level+1); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package splitter
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestNewLevelSplitter(t *testing.T) {
+	s, err := NewLevelSplitter(MustCreateSplitter(';'), MustCreateSplitter(','))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	require.Equal(t, 2, s.Levels())
+
+	_, err = NewLevelSplitter()
+	require.Error(t, err)
+	require.Equal(t, "at least one level must be specified", err.Error())
+
+	_, err = NewLevelSplitter(MustCreateSplitter(';'), nil)
+	require.Error(t, err)
+	require.Equal(t, "invalid splitter (level[2])", err.Error())
+
+	require.NotPanics(t, func() {
+		MustCreateLevelSplitter(MustCreateSplitter(';'))
+	})
+	require.Panics(t, func() {
+		MustCreateLevelSplitter()
+	})
+}
+
+func TestLevelSplitter_Split(t *testing.T) {
+	s := MustCreateLevelSplitter(
+		MustCreateSplitter(';', DoubleQuotes, Parenthesis).AddDefaultOptions(TrimSpaces, IgnoreEmpties),
+		MustCreateSplitter(',', DoubleQuotes, Parenthesis).AddDefaultOptions(TrimSpaces),
+		MustCreateSplitter('=', DoubleQuotes, Parenthesis).AddDefaultOptions(StripQuotes, TrimSpaces),
+	)
+
+	parts, err := s.Split(`a=1, b="2;3" ; c = (4,5);`)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(parts))
+	require.Equal(t, `a=1, b="2;3"`, parts[0].Value)
+	require.Equal(t, `;`, parts[0].Separator)
+	require.Equal(t, []string{`a=1`, `b="2;3"`}, parts[0].Values())
+	require.Equal(t, []string{`a`, `1`}, parts[0].Parts[0].Values())
+	require.Equal(t, []string{`b`, `2;3`}, parts[0].Parts[1].Values())
+	require.Equal(t, `c = (4,5)`, parts[1].Value)
+	require.Equal(t, []string{`c = (4,5)`}, parts[1].Values())
+	require.Equal(t, []string{`c`, `(4,5)`}, parts[1].Parts[0].Values())
+	require.Nil(t, parts[1].Parts[0].Parts[1].Parts)
+	require.Nil(t, parts[1].Parts[0].Parts[1].Values())
+}
+
+func TestLevelSplitter_Split_OtherSplitters(t *testing.T) {
+	s := MustCreateLevelSplitter(
+		MustCreateWhitespaceSplitter(DoubleQuotes),
+		MustCreateStringSplitter("::", DoubleQuotes),
+	)
+
+	parts, err := s.Split(` a::b   "c d"::e `)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(parts))
+	require.Equal(t, []string{`a`, `b`}, parts[0].Values())
+	require.Equal(t, []string{`"c d"`, `e`}, parts[1].Values())
+}
+
+func TestLevelSplitter_Split_Errors(t *testing.T) {
+	s := MustCreateLevelSplitter(
+		MustCreateSplitter(';', DoubleQuotes),
+		MustCreateSplitter(',', DoubleQuotes, Parenthesis),
+		MustCreateSplitter('=', DoubleQuotes, Parenthesis, SquareBrackets).AddDefaultOptions(NoEmpties),
+	)
+
+	testCases := []struct {
+		str       string
+		expectErr string
+		expectPos int
+	}{
+		{
+			`a;"b`,
+			fmt.Sprintf(unclosedFmt, `"`, 2),
+			2,
+		},
+		{
+			`a;b,c,(d`,
+			fmt.Sprintf(unclosedFmt, `(`, 6),
+			6,
+		},
+		{
+			`a;b,c,d=[e`,
+			fmt.Sprintf(unclosedFmt, `[`, 8),
+			8,
+		},
+		{
+			`a;b,c,d=e]`,
+			fmt.Sprintf(unopenedFmt, `]`, 9),
+			9,
+		},
+		{
+			`a;b,c,d==e`,
+			_NoEmpties.message,
+			8,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			_, err := s.Split(tc.str)
+			require.Error(t, err)
+			require.Equal(t, tc.expectErr, err.Error())
+			sErr, ok := err.(SplittingError)
+			require.True(t, ok)
+			require.Equal(t, tc.expectPos, sErr.Position())
+		})
+	}
+}
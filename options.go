@@ -8,6 +8,15 @@ type Option interface {
 	Apply(s string, pos int, totalLen int, captured int, skipped int, isLast bool, subParts ...SubPart) (string, bool, error)
 }
 
+// SeparatorOption is an Option that also needs to examine the separator that terminated the split part
+//
+// If an option implements this interface, ApplySeparator is called instead of Apply - the `sep` arg is
+// the separator that terminated the split part (or nil if the part was terminated by the end of the string)
+type SeparatorOption interface {
+	Option
+	ApplySeparator(s string, pos int, totalLen int, captured int, skipped int, isLast bool, sep Separator, subParts ...SubPart) (string, bool, error)
+}
+
 var (
 	TrimSpaces            Option = _TrimSpaces            // TrimSpaces causes split parts to be trimmed of leading & trailing spaces
 	Trim                         = _Trim                  // Trim causes split parts to be trimmed of the leading & trailing custsets specified
@@ -34,6 +43,7 @@ var (
 	NoMultisMsg                  = _NoMultisMsg           // NoMultisMsg is the same as NoMultis but allows a custom error message
 	StripQuotes           Option = _StripQuotes           // StripQuotes causes quotes within a split part to be stripped
 	UnescapeQuotes        Option = _UnescapeQuotes        // UnescapeQuotes causes any quotes within the split part to have any escaped end quotes to be removed
+	UnescapeSeparators    Option = _UnescapeSeparators    // UnescapeSeparators causes any escaped separators (see Splitter.SetSeparatorEscape) within the split part to have the escape runes removed
 )
 
 var (
@@ -78,8 +88,9 @@ var (
 	_NoMultisMsg = func(message string) Option {
 		return &noMultis{message: message}
 	}
-	_StripQuotes    = &stripQuotes{}
-	_UnescapeQuotes = &unescapeQuotes{}
+	_StripQuotes        = &stripQuotes{}
+	_UnescapeQuotes     = &unescapeQuotes{}
+	_UnescapeSeparators = &unescapeSeparators{}
 )
 
 type trim struct {
@@ -248,8 +259,7 @@ type stripQuotes struct {
 func (o *stripQuotes) Apply(s string, pos int, totalLen int, captured int, skipped int, isLast bool, subParts ...SubPart) (string, bool, error) {
 	if len(subParts) == 1 {
 		if subParts[0].IsQuote() {
-			str := subParts[0].String()
-			return str[1 : len(str)-1], true, nil
+			return stripEnclosure(subParts[0]), true, nil
 		}
 		return s, true, nil
 	}
@@ -258,13 +268,20 @@ func (o *stripQuotes) Apply(s string, pos int, totalLen int, captured int, skipp
 	for _, sub := range subParts {
 		str := sub.String()
 		if sub.IsQuote() {
-			str = str[1 : len(str)-1]
+			str = stripEnclosure(sub)
 		}
 		sb.WriteString(str)
 	}
 	return sb.String(), true, nil
 }
 
+// stripEnclosure returns the string of the sub-part without its enclosure start & end
+func stripEnclosure(sub SubPart) string {
+	str := sub.String()
+	enc := sub.Enclosure()
+	return str[len(sub.Prefix())+len(enc.startSeq()) : len(str)-len(enc.endSeq())]
+}
+
 type unescapeQuotes struct {
 }
 
@@ -285,3 +302,42 @@ func (o *unescapeQuotes) Apply(s string, pos int, totalLen int, captured int, sk
 	}
 	return sb.String(), true, nil
 }
+
+type unescapeSeparators struct {
+}
+
+func (o *unescapeSeparators) Apply(s string, pos int, totalLen int, captured int, skipped int, isLast bool, subParts ...SubPart) (string, bool, error) {
+	// when splitting, applyIn is used - otherwise, the escaped fixed text of sub-parts is replaced (as found, in order)...
+	var sb strings.Builder
+	last := 0
+	for _, sub := range subParts {
+		if !sub.IsFixed() {
+			continue
+		} else if str, unescaped := sub.String(), sub.UnEscaped(); str != unescaped {
+			if at := strings.Index(s[last:], str); at != -1 {
+				sb.WriteString(s[last : last+at])
+				sb.WriteString(unescaped)
+				last += at + len(str)
+			}
+		}
+	}
+	if last == 0 {
+		return s, true, nil
+	}
+	sb.WriteString(s[last:])
+	return sb.String(), true, nil
+}
+
+func (o *unescapeSeparators) applyIn(ctx *splitterContext, s string) string {
+	if ctx.value != nil {
+		// the escapes are removed from the value as passed (which may already have been trimmed, had quotes stripped etc.
+		// by preceding options) - using the tracked segments of the value...
+		return ctx.segmentsString(ctx.withoutEscapes(ctx.value))
+	}
+	return ctx.unescapedText(s)
+}
+
+// contextOption is implemented by options that use the splitting context when applied during splitting
+type contextOption interface {
+	applyIn(ctx *splitterContext, s string) string
+}
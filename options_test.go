@@ -2,6 +2,7 @@ package splitter
 
 import (
 	"github.com/stretchr/testify/require"
+	"strings"
 	"testing"
 )
 
@@ -472,3 +473,93 @@ func TestOption_UnescapeQuotes(t *testing.T) {
 	require.Equal(t, 1, len(pts))
 	require.Equal(t, `a`, pts[0])
 }
+
+func TestOption_UnescapeSeparators(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotesBackSlashEscaped)
+	require.NoError(t, err)
+	s.SetSeparatorEscape('\\')
+
+	pts, err := s.Split(`a\,b,c`, UnescapeSeparators)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a,b`, `c`}, pts)
+
+	pts, err = s.Split(`a\\,b\\\,c,\d`, UnescapeSeparators)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a\`, `b\,c`, `\d`}, pts)
+
+	pts, err = s.Split(`a\,"b\,\"c",d`, UnescapeSeparators)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a,"b\,\"c"`, `d`}, pts)
+
+	pts, err = s.Split(`a,b`, UnescapeSeparators)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `b`}, pts)
+
+	pts, err = s.Split(``, UnescapeSeparators)
+	require.NoError(t, err)
+	require.Equal(t, []string{``}, pts)
+
+	s2, err := NewStringSplitter(".")
+	require.NoError(t, err)
+	s2.SetSeparatorEscape('\\').AddDefaultOptions(UnescapeSeparators)
+	pts, err = s2.Split(`a\.b.c`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a.b`, `c`}, pts)
+
+	// escapes are removed from the value passed by preceding options...
+	pts, err = s.Split(` a\,b , c`, TrimSpaces, UnescapeSeparators)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a,b`, `c`}, pts)
+	pts, err = s.Split(`"x" , c`, StripQuotes, UnescapeSeparators)
+	require.NoError(t, err)
+	require.Equal(t, []string{`x `, ` c`}, pts)
+	pts, err = s.Split(`"x", c`, TrimSpaces, StripQuotes, UnescapeSeparators)
+	require.NoError(t, err)
+	require.Equal(t, []string{`x`, `c`}, pts)
+	pts, err = s.Split(`"x\,y", a\,b`, TrimSpaces, StripQuotes, UnescapeSeparators)
+	require.NoError(t, err)
+	require.Equal(t, []string{`x\,y`, `a,b`}, pts)
+	pts, err = s.Split(` a\,b , c`, UnescapeSeparators, TrimSpaces)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a,b`, `c`}, pts)
+	pts, err = s.Split(`"x" a\,b`, StripQuotes, UnescapeSeparators)
+	require.NoError(t, err)
+	require.Equal(t, []string{`x a,b`}, pts)
+	pts, err = s.Split(`"x" a\,b`, StripQuotes, UnescapeSeparators, Trim("xb"))
+	require.NoError(t, err)
+	require.Equal(t, []string{` a,`}, pts)
+	// escapes are removed from values otherwise changed by preceding options...
+	pts, err = s.Split(`a\,b\\,c`, &upperOption{}, UnescapeSeparators)
+	require.NoError(t, err)
+	require.Equal(t, []string{`A,B\`, `C`}, pts)
+
+	cs, err := NewSplitter(',', HashLineComments)
+	require.NoError(t, err)
+	cs.SetSeparatorEscape('\\').SetCommentPolicy(CommentsStrip)
+	pts, err = cs.Split("a\\,b # c\n,d", UnescapeSeparators)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a,b \n", "d"}, pts)
+	parts, err := cs.SplitParts("a # c\nb\\,c,d", UnescapeSeparators)
+	require.NoError(t, err)
+	require.Equal(t, "a \nb,c", parts[0].Value)
+	require.Equal(t, parts[0].Span, parts[0].ValueSpan)
+	parts, err = cs.SplitParts("a # c\nb\\,c,d", UnescapeSeparators, Trim("a #\n"))
+	require.NoError(t, err)
+	require.Equal(t, "b,c", parts[0].Value)
+	require.Equal(t, parts[0].Span, parts[0].ValueSpan)
+	parts, err = cs.SplitParts("b\\,c # c\n,d", UnescapeSeparators, Trim("b #\n"))
+	require.NoError(t, err)
+	require.Equal(t, ",c", parts[0].Value)
+	require.Equal(t, 2, parts[0].ValueSpan.Start.Byte)
+	require.Equal(t, 4, parts[0].ValueSpan.End.Byte)
+
+	// applied directly (i.e. not whilst splitting)...
+	str, _, _ := UnescapeSeparators.Apply(`x`, 0, 0, 0, 0, false)
+	require.Equal(t, `x`, str)
+}
+
+type upperOption struct{}
+
+func (o *upperOption) Apply(s string, pos int, totalLen int, captured int, skipped int, isLast bool, subParts ...SubPart) (string, bool, error) {
+	return strings.ToUpper(s), true, nil
+}
@@ -0,0 +1,61 @@
+package splitter
+
+// Part is a split part as returned by Splitter.SplitParts
+type Part struct {
+	// Value is the final text of the part (after any options have been applied)
+	Value string
+	// Raw is the raw text of the part (before any options were applied)
+	Raw string
+	// Span is the position of the raw text of the part within the original string
+	Span Span
+	// ValueSpan is the position of the final text of the part within the original string
+	//
+	// If options changed the text other than by trimming it (e.g. TrimSpaces), stripping quotes (i.e. StripQuotes) or
+	// unescaping separators (i.e. UnescapeSeparators) - or the final text is not contiguous within the original string -
+	// this is the same as Span
+	ValueSpan Span
+	// Separator is the separator that terminated the part (empty string if terminated by the end of the string)
+	Separator string
+	// SubParts is the sub-parts found in the raw part (excluding any comments stripped - see CommentsStrip)
+	SubParts []SubPart
+}
+
+// Span is the start (inclusive) and end (exclusive) positions of a part within the original string
+type Span struct {
+	Start Position
+	End   Position
+}
+
+// Position is a position within the original string
+type Position struct {
+	// Rune is the position in runes
+	Rune int
+	// Byte is the position in UTF-8 bytes
+	Byte int
+	// UTF16 is the position in UTF-16 code units (e.g. as used by LSP and JavaScript)
+	UTF16 int
+}
+
+func (ctx *splitterContext) newPart(start int, end int, raw string, value string, sep Separator, subParts []SubPart) Part {
+	result := Part{
+		Value: value,
+		Raw:   raw,
+		Span: Span{
+			Start: ctx.position(start),
+			End:   ctx.position(end),
+		},
+		SubParts: subParts,
+	}
+	result.ValueSpan = result.Span
+	if value != raw && len(ctx.value) == 1 {
+		// the position of the value has been tracked as options were applied...
+		result.ValueSpan = Span{
+			Start: ctx.position(ctx.value[0].start),
+			End:   ctx.position(ctx.value[0].end),
+		}
+	}
+	if sep != nil {
+		result.Separator = sep.String()
+	}
+	return result
+}
@@ -0,0 +1,137 @@
+package splitter
+
+import (
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSplitter_SplitParts(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	parts, err := s.SplitParts(`a, "b,c" ,(d)`)
+	//                          0123456789012
+	require.NoError(t, err)
+	require.Equal(t, 3, len(parts))
+
+	require.Equal(t, `a`, parts[0].Value)
+	require.Equal(t, `a`, parts[0].Raw)
+	require.Equal(t, `,`, parts[0].Separator)
+	require.Equal(t, Span{Start: Position{0, 0, 0}, End: Position{1, 1, 1}}, parts[0].Span)
+	require.Equal(t, parts[0].Span, parts[0].ValueSpan)
+	require.Equal(t, 1, len(parts[0].SubParts))
+
+	require.Equal(t, ` "b,c" `, parts[1].Value)
+	require.Equal(t, Span{Start: Position{2, 2, 2}, End: Position{9, 9, 9}}, parts[1].Span)
+	require.Equal(t, 3, len(parts[1].SubParts))
+	require.True(t, parts[1].SubParts[1].IsQuote())
+
+	require.Equal(t, `(d)`, parts[2].Value)
+	require.Equal(t, ``, parts[2].Separator)
+	require.Equal(t, Span{Start: Position{10, 10, 10}, End: Position{13, 13, 13}}, parts[2].Span)
+	require.Equal(t, 1, len(parts[2].SubParts))
+	require.True(t, parts[2].SubParts[0].IsBrackets())
+}
+
+func TestSplitter_SplitParts_MultiByte(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes)
+	require.NoError(t, err)
+
+	parts, err := s.SplitParts(`ä,"😀",b`)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(parts))
+	require.Equal(t, Span{Start: Position{0, 0, 0}, End: Position{1, 2, 1}}, parts[0].Span)
+	require.Equal(t, Span{Start: Position{2, 3, 2}, End: Position{5, 9, 6}}, parts[1].Span)
+	require.Equal(t, Span{Start: Position{6, 10, 7}, End: Position{7, 11, 8}}, parts[2].Span)
+}
+
+func TestSplitter_SplitParts_Options(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes)
+	require.NoError(t, err)
+
+	parts, err := s.SplitParts(` ä , "😀" ,, b `, TrimSpaces, IgnoreEmpties)
+	//                          0123456789012345
+	require.NoError(t, err)
+	require.Equal(t, 3, len(parts))
+
+	require.Equal(t, `ä`, parts[0].Value)
+	require.Equal(t, ` ä `, parts[0].Raw)
+	require.Equal(t, Span{Start: Position{0, 0, 0}, End: Position{3, 4, 3}}, parts[0].Span)
+	require.Equal(t, Span{Start: Position{1, 1, 1}, End: Position{2, 3, 2}}, parts[0].ValueSpan)
+
+	require.Equal(t, `"😀"`, parts[1].Value)
+	require.Equal(t, Span{Start: Position{4, 5, 4}, End: Position{9, 13, 10}}, parts[1].Span)
+	require.Equal(t, Span{Start: Position{5, 6, 5}, End: Position{8, 12, 9}}, parts[1].ValueSpan)
+
+	require.Equal(t, `b`, parts[2].Value)
+	require.Equal(t, Span{Start: Position{11, 15, 12}, End: Position{14, 18, 15}}, parts[2].Span)
+	require.Equal(t, Span{Start: Position{12, 16, 13}, End: Position{13, 17, 14}}, parts[2].ValueSpan)
+
+	parts, err = s.SplitParts(`"a""b"`, StripQuotes)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(parts))
+	require.Equal(t, `ab`, parts[0].Value)
+	require.Equal(t, parts[0].Span, parts[0].ValueSpan)
+}
+
+func TestSplitter_SplitParts_ValueSpan(t *testing.T) {
+	s, err := NewSplitter(',', RawPrefixedDoubleQuotes, DoubleQuotes)
+	require.NoError(t, err)
+
+	// the value also occurs earlier in the raw text...
+	parts, err := s.SplitParts(`r"r",x"x"`, StripQuotes)
+	//                          0123456789
+	require.NoError(t, err)
+	require.Equal(t, 2, len(parts))
+	require.Equal(t, `r`, parts[0].Value)
+	require.Equal(t, Span{Start: Position{2, 2, 2}, End: Position{3, 3, 3}}, parts[0].ValueSpan)
+	require.Equal(t, `xx`, parts[1].Value)
+	require.Equal(t, parts[1].Span, parts[1].ValueSpan)
+
+	parts, err = s.SplitParts(`a, x x ,b`, Trim(" x"))
+	//                          0123456789
+	require.NoError(t, err)
+	require.Equal(t, 3, len(parts))
+	require.Equal(t, ``, parts[1].Value)
+	require.Equal(t, Span{Start: Position{7, 7, 7}, End: Position{7, 7, 7}}, parts[1].ValueSpan)
+	require.Equal(t, `a`, parts[0].Value)
+	require.Equal(t, parts[0].Span, parts[0].ValueSpan)
+}
+
+func TestSplitter_SplitParts_Comments(t *testing.T) {
+	s, err := NewSplitter(',', BlockComments, Parenthesis)
+	require.NoError(t, err)
+
+	parts, err := s.SplitParts(`a /* x */ (b),c`)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(parts))
+	require.Equal(t, `a  (b)`, parts[0].Value)
+	require.Equal(t, 3, len(parts[0].SubParts))
+	for _, sp := range parts[0].SubParts {
+		require.False(t, sp.IsComment())
+	}
+
+	// nested comments are not stripped (so the text of the part remains the same as that of its sub-parts)...
+	parts, err = s.SplitParts(`a /* x */ (b /* y */ c),d`)
+	require.NoError(t, err)
+	require.Equal(t, `a  (b /* y */ c)`, parts[0].Value)
+	require.Equal(t, 3, len(parts[0].SubParts))
+	require.Equal(t, `(b /* y */ c)`, parts[0].SubParts[2].String())
+
+	s.SetCommentPolicy(CommentsKeep)
+	parts, err = s.SplitParts(`a /* x */ (b),c`)
+	require.NoError(t, err)
+	require.Equal(t, `a /* x */ (b)`, parts[0].Value)
+	require.Equal(t, 4, len(parts[0].SubParts))
+	require.True(t, parts[0].SubParts[1].IsComment())
+}
+
+func TestSplitter_SplitParts_Errors(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes)
+	require.NoError(t, err)
+
+	_, err = s.SplitParts(`a,"b`)
+	require.Error(t, err)
+	_, err = s.SplitParts(`a,,b`, NoEmpties)
+	require.Error(t, err)
+}
@@ -0,0 +1,55 @@
+package splitter
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+)
+
+func (s *splitter) ReplaceSeparators(str string, new string) (string, error) {
+	return s.replace(str, s.sep, func(*splitterContext, string) string {
+		return new
+	})
+}
+
+func (s *splitter) ReplaceOutside(str string, old string, new string) (string, error) {
+	if old == "" {
+		return "", errors.New("old cannot be empty")
+	}
+	return s.replace(str, stringSeparator(old), func(*splitterContext, string) string {
+		return new
+	})
+}
+
+func (s *splitter) ReplaceRegexpOutside(str string, rx *regexp.Regexp, repl string) (string, error) {
+	if rx == nil {
+		return "", errors.New("regexp cannot be nil")
+	}
+	// the replacement is expanded using the submatches found in the whole string (rather than just the matched text)...
+	return s.replace(str, &regexpSeparator{rx: rx, submatches: true}, func(ctx *splitterContext, _ string) string {
+		return string(rx.ExpandString(nil, repl, str, ctx.rxMatches[0]))
+	})
+}
+
+// replace rebuilds the string with every match of the matcher (found in the same way as separators are found when splitting)
+// replaced by the result of the repl func
+func (s *splitter) replace(str string, m separatorMatcher, repl func(ctx *splitterContext, matched string) string) (string, error) {
+	cp := *s
+	cp.separator = 0
+	cp.sep = m
+	cp.skipEmpties = false
+	ctx := newLazySplitterContext(str, 0, len(str), &cp, nil)
+	var sb strings.Builder
+	sb.Grow(len(str))
+	ctx.yield = func(part Part) bool {
+		sb.WriteString(str[part.Span.Start.Byte:part.Span.End.Byte])
+		if part.Separator != "" {
+			sb.WriteString(repl(ctx, part.Separator))
+		}
+		return true
+	}
+	if _, err := ctx.split(); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,118 @@
+package splitter
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"testing"
+)
+
+func TestSplitter_ReplaceSeparators(t *testing.T) {
+	s, err := NewSplitter(';', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str    string
+		expect string
+	}{
+		{``, ``},
+		{`;`, `,`},
+		{`a;b;c`, `a,b,c`},
+		{` a ; "b;c" ;(d;e);`, ` a , "b;c" ,(d;e),`},
+		{`日;本`, `日,本`},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			result, err := s.ReplaceSeparators(tc.str, `,`)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, result)
+		})
+	}
+
+	_, err = s.ReplaceSeparators(`a;"b`, `,`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `"`, 2), err.Error())
+
+	es, err := NewSplitter(';', DoubleQuotes)
+	require.NoError(t, err)
+	es.SetSeparatorEscape('\\')
+	result, err := es.ReplaceSeparators(`a\;b;c\\;d`, `,`)
+	require.NoError(t, err)
+	require.Equal(t, `a\;b,c\\,d`, result)
+
+	ws, err := NewWhitespaceSplitter(DoubleQuotes)
+	require.NoError(t, err)
+	result, err = ws.ReplaceSeparators(`  a   "b  c"  d `, ` `)
+	require.NoError(t, err)
+	require.Equal(t, ` a "b  c" d `, result)
+
+	ss, err := NewStringSplitter(", ", DoubleQuotes)
+	require.NoError(t, err)
+	result, err = ss.ReplaceSeparators(`a, b, "c, d"`, `,`)
+	require.NoError(t, err)
+	require.Equal(t, `a,b,"c, d"`, result)
+}
+
+func TestSplitter_ReplaceOutside(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	result, err := s.ReplaceOutside(`a, b, "c, d", (e, f)`, `, `, `,`)
+	require.NoError(t, err)
+	require.Equal(t, `a,b,"c, d",(e, f)`, result)
+	result, err = s.ReplaceOutside(`aaa "aa"`, `aa`, `b`)
+	require.NoError(t, err)
+	require.Equal(t, `ba "aa"`, result)
+
+	s.SetSeparatorEscape('\\')
+	result, err = s.ReplaceOutside(`a=b\=c`, `=`, `:`)
+	require.NoError(t, err)
+	require.Equal(t, `a:b\=c`, result)
+
+	_, err = s.ReplaceOutside(`a`, ``, `b`)
+	require.Error(t, err)
+	_, err = s.ReplaceOutside(`a)`, `a`, `b`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unopenedFmt, `)`, 1), err.Error())
+}
+
+func TestSplitter_ReplaceRegexpOutside(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	result, err := s.ReplaceRegexpOutside(`a ,  b,c  , "d , e"`, regexp.MustCompile(`\s*,\s*`), `,`)
+	require.NoError(t, err)
+	require.Equal(t, `a,b,c,"d , e"`, result)
+	result, err = s.ReplaceRegexpOutside(`x=1 (y=2) "z=3"`, regexp.MustCompile(`(\w+)=(\d+)`), `$2=$1`)
+	require.NoError(t, err)
+	require.Equal(t, `1=x (y=2) "z=3"`, result)
+	// replacements are expanded from the match in the whole string...
+	result, err = s.ReplaceRegexpOutside(`ab,"ab",xab`, regexp.MustCompile(`(\w)(b)`), `$2$1`)
+	require.NoError(t, err)
+	require.Equal(t, `ba,"ab",xba`, result)
+	result, err = s.ReplaceRegexpOutside(`a1 (b2) b3`, regexp.MustCompile(`(a|b)(\d)`), `${1}x${2}`)
+	require.NoError(t, err)
+	require.Equal(t, `ax1 (b2) bx3`, result)
+	result, err = s.ReplaceRegexpOutside(`ab "ab" b`, regexp.MustCompile(`\B(b)`), `[$1]`)
+	require.NoError(t, err)
+	require.Equal(t, `a[b] "ab" b`, result)
+	rx := regexp.MustCompile(`a|ab`)
+	rx.Longest()
+	result, err = s.ReplaceRegexpOutside(`xab "ab"`, rx, `-`)
+	require.NoError(t, err)
+	require.Equal(t, `x- "ab"`, result)
+	result, err = s.ReplaceRegexpOutside(`xab "ab"`, regexp.MustCompilePOSIX(`a|ab`), `-`)
+	require.NoError(t, err)
+	require.Equal(t, `x- "ab"`, result)
+	result, err = s.ReplaceRegexpOutside(`xxx "x"`, regexp.MustCompile(`^x`), `y`)
+	require.NoError(t, err)
+	require.Equal(t, `yxx "x"`, result)
+	result, err = s.ReplaceRegexpOutside(`x,xx "x"`, regexp.MustCompile(`\bx`), `y`)
+	require.NoError(t, err)
+	require.Equal(t, `y,yx "x"`, result)
+
+	_, err = s.ReplaceRegexpOutside(`a`, nil, `b`)
+	require.Error(t, err)
+	_, err = s.ReplaceRegexpOutside(`a (`, regexp.MustCompile(`a`), `b`)
+	require.Error(t, err)
+}
@@ -0,0 +1,116 @@
+package splitter
+
+import "errors"
+
+// NotFound is the position returned by the search methods (e.g. Splitter.IndexOutside) when the search is not found
+var NotFound = Position{Rune: -1, Byte: -1, UTF16: -1}
+
+func (s *splitter) IndexOutside(str string, substr string) (Position, error) {
+	if substr == "" {
+		return NotFound, errors.New("substr cannot be empty")
+	}
+	ctx, ss, err := s.search(str, stringSeparator(substr))
+	if err != nil || len(ss.found) == 0 {
+		return NotFound, err
+	}
+	return ctx.position(ss.found[0]), nil
+}
+
+func (s *splitter) LastIndexOutside(str string, substr string) (Position, error) {
+	if substr == "" {
+		return NotFound, errors.New("substr cannot be empty")
+	}
+	ctx, ss, err := s.search(str, stringSeparator(substr))
+	if err != nil || len(ss.found) == 0 {
+		return NotFound, err
+	}
+	return ctx.position(ss.found[len(ss.found)-1]), nil
+}
+
+func (s *splitter) ContainsOutside(str string, substr string) (bool, error) {
+	if substr == "" {
+		return false, errors.New("substr cannot be empty")
+	}
+	_, ss, err := s.search(str, stringSeparator(substr))
+	if err != nil {
+		return false, err
+	}
+	return len(ss.found) > 0, nil
+}
+
+func (s *splitter) CountOutside(str string, substr string) (int, error) {
+	if substr == "" {
+		return 0, errors.New("substr cannot be empty")
+	}
+	_, ss, err := s.search(str, stringSeparator(substr))
+	if err != nil {
+		return 0, err
+	}
+	// count non-overlapping occurrences...
+	count, next := 0, 0
+	for _, pos := range ss.found {
+		if pos >= next {
+			count++
+			next = pos + len(substr)
+		}
+	}
+	return count, nil
+}
+
+func (s *splitter) IndexAnyOutside(str string, runes ...rune) (Position, error) {
+	// (with no runes, nothing is found - but the string is still checked for unbalanced enclosures)...
+	rs := runeSetSeparator{}
+	for _, r := range runes {
+		rs[r] = true
+	}
+	ctx, ss, err := s.search(str, rs)
+	if err != nil || len(ss.found) == 0 {
+		return NotFound, err
+	}
+	return ctx.position(ss.found[0]), nil
+}
+
+func (s *splitter) FieldsOutside(str string, options ...Option) ([]string, error) {
+	cp := *s
+	cp.separator = 0
+	cp.sep = whitespaceSeparator{}
+	cp.skipEmpties = true
+	cp.sepEscape = 0
+	return newSplitterContext(str, &cp, s.mergeOptions(options)).split()
+}
+
+// search scans the string (with the splitter's enclosures) recording all the (byte) positions, outside of any enclosures,
+// where the supplied matcher matches
+func (s *splitter) search(str string, m separatorMatcher) (*splitterContext, *searchSeparator, error) {
+	ss := &searchSeparator{
+		sep:   m,
+		found: make([]int, 0),
+	}
+	cp := *s
+	cp.separator = 0
+	cp.sep = ss
+	cp.skipEmpties = false
+	cp.sepEscape = 0
+	ctx := newLazySplitterContext(str, 0, len(str), &cp, nil)
+	ctx.yield = func(Part) bool {
+		return true
+	}
+	if _, err := ctx.split(); err != nil {
+		return nil, nil, err
+	}
+	return ctx, ss, nil
+}
+
+// searchSeparator is a separator matcher that never splits - instead recording the positions at which the
+// underlying matcher matches (the splitter context only asks for separators outside of enclosures)
+type searchSeparator struct {
+	sep   separatorMatcher
+	found []int
+}
+
+func (s *searchSeparator) match(ctx *splitterContext) int {
+	if s.sep.match(ctx) > 0 {
+		s.found = append(s.found, ctx.pos)
+	}
+	return 0
+}
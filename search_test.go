@@ -0,0 +1,150 @@
+package splitter
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"strings"
+	"testing"
+)
+
+func TestSplitter_IndexOutside(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str    string
+		substr string
+		expect int
+	}{
+		{``, `=`, -1},
+		{`a=b`, `=`, 1},
+		{`"a=b"=c`, `=`, 5},
+		{`(a=b)`, `=`, -1},
+		{`(a=(b=c))=d=e`, `=`, 9},
+		{`"#" # x`, `#`, 4},
+		{`a == b`, `==`, 2},
+		{`"==" (==) ==`, `==`, 10},
+		{`f(x)`, `(`, 1},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			pos, err := s.IndexOutside(tc.str, tc.substr)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, pos.Rune)
+		})
+	}
+
+	pos, err := s.IndexOutside(`"日本"=語`, `=`)
+	require.NoError(t, err)
+	require.Equal(t, Position{Rune: 4, Byte: 8, UTF16: 4}, pos)
+	str := `"日本"=語`
+	require.Equal(t, `語`, str[pos.Byte+1:])
+
+	pos, err = s.IndexOutside(`a`, `b`)
+	require.NoError(t, err)
+	require.Equal(t, NotFound, pos)
+
+	_, err = s.IndexOutside(`a=(b`, `=`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, "(", 2), err.Error())
+	_, err = s.IndexOutside(`a`, ``)
+	require.Error(t, err)
+}
+
+func TestSplitter_LastIndexOutside(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	pos, err := s.LastIndexOutside(`a=b=c"="(=)`, `=`)
+	require.NoError(t, err)
+	require.Equal(t, 3, pos.Rune)
+	pos, err = s.LastIndexOutside(`aaa`, `aa`)
+	require.NoError(t, err)
+	require.Equal(t, strings.LastIndex(`aaa`, `aa`), pos.Rune)
+	pos, err = s.LastIndexOutside(`"a"`, `a`)
+	require.NoError(t, err)
+	require.Equal(t, NotFound, pos)
+
+	_, err = s.LastIndexOutside(`a=b)`, `=`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unopenedFmt, ")", 3), err.Error())
+	_, err = s.LastIndexOutside(`a`, ``)
+	require.Error(t, err)
+}
+
+func TestSplitter_ContainsOutside(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	contains, err := s.ContainsOutside(`a "b=c"`, `=`)
+	require.NoError(t, err)
+	require.False(t, contains)
+	contains, err = s.ContainsOutside(`a "b=c" =`, `=`)
+	require.NoError(t, err)
+	require.True(t, contains)
+
+	_, err = s.ContainsOutside(`a "b=c`, `=`)
+	require.Error(t, err)
+	_, err = s.ContainsOutside(`a`, ``)
+	require.Error(t, err)
+}
+
+func TestSplitter_CountOutside(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	count, err := s.CountOutside(`a,"b,c",(d,e),f`, `,`)
+	require.NoError(t, err)
+	require.Equal(t, 3, count)
+	count, err = s.CountOutside(`aaaa`, `aa`)
+	require.NoError(t, err)
+	require.Equal(t, strings.Count(`aaaa`, `aa`), count)
+	count, err = s.CountOutside(`aaa`, `aa`)
+	require.NoError(t, err)
+	require.Equal(t, strings.Count(`aaa`, `aa`), count)
+	count, err = s.CountOutside(`(a,b)`, `,`)
+	require.NoError(t, err)
+	require.Equal(t, 0, count)
+
+	_, err = s.CountOutside(`a,(b`, `,`)
+	require.Error(t, err)
+	_, err = s.CountOutside(`a`, ``)
+	require.Error(t, err)
+}
+
+func TestSplitter_IndexAnyOutside(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	pos, err := s.IndexAnyOutside(`"a=b:c" (d:e) f:g=h`, '=', ':')
+	require.NoError(t, err)
+	require.Equal(t, 15, pos.Rune)
+	pos, err = s.IndexAnyOutside(`"a=b:c"`, '=', ':')
+	require.NoError(t, err)
+	require.Equal(t, NotFound, pos)
+	pos, err = s.IndexAnyOutside(`a=b`)
+	require.NoError(t, err)
+	require.Equal(t, NotFound, pos)
+
+	_, err = s.IndexAnyOutside(`a=b"`, '=')
+	require.Error(t, err)
+	pos, err = s.IndexAnyOutside(`a=(b`)
+	require.Error(t, err)
+	require.Equal(t, NotFound, pos)
+}
+
+func TestSplitter_FieldsOutside(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	fields, err := s.FieldsOutside(`  a "b c"  (d e)	f  `)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `"b c"`, `(d e)`, `f`}, fields)
+
+	fields, err = s.FieldsOutside(`a "b c"`, StripQuotes)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `b c`}, fields)
+
+	_, err = s.FieldsOutside(`a "b c`)
+	require.Error(t, err)
+}
@@ -0,0 +1,358 @@
+package splitter
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NewStringSplitter creates a new splitter that splits on a (possibly multi-rune) string separator
+//
+// the `separator` arg is the string on which to split (e.g. "::", "->" or " AND ")
+//
+// the optional `encs` varargs are the enclosures (e.g. brackets, quotes) to be taken into consideration when splitting
+//
+// An error is returned if the separator is empty, if the separator is the start of any of the enclosures or if any of enclosures specified match any other enclosure `Start`/`End`
+func NewStringSplitter(separator string, encs ...*Enclosure) (Splitter, error) {
+	if separator == "" {
+		return nil, errors.New("separator cannot be empty")
+	}
+	r, _ := utf8.DecodeRuneInString(separator)
+	return newSplitter(r, stringSeparator(separator), encs)
+}
+
+// MustCreateStringSplitter is the same as NewStringSplitter, except that it panics in case of error
+func MustCreateStringSplitter(separator string, encs ...*Enclosure) Splitter {
+	if s, err := NewStringSplitter(separator, encs...); err != nil {
+		panic(err)
+	} else {
+		return s
+	}
+}
+
+// NewMultiSplitter creates a new splitter that splits on any one of a set of separator runes
+//
+// the `separators` arg is the set of runes on which to split (e.g. []rune{',', ';', '|'})
+//
+// the optional `encs` varargs are the enclosures (e.g. brackets, quotes) to be taken into consideration when splitting
+//
+// the Splitter.SplitWithSeparators method (or an option implementing SeparatorOption) can be used to determine which separator terminated each split part
+//
+// An error is returned if no separators are specified, if any separator is the start of any of the enclosures or if any of enclosures specified match any other enclosure `Start`/`End`
+func NewMultiSplitter(separators []rune, encs ...*Enclosure) (Splitter, error) {
+	if len(separators) == 0 {
+		return nil, errors.New("at least one separator must be specified")
+	}
+	sep := runeSetSeparator{}
+	for _, r := range separators {
+		sep[r] = true
+	}
+	return newSplitter(0, sep, encs)
+}
+
+// MustCreateMultiSplitter is the same as NewMultiSplitter, except that it panics in case of error
+func MustCreateMultiSplitter(separators []rune, encs ...*Enclosure) Splitter {
+	if s, err := NewMultiSplitter(separators, encs...); err != nil {
+		panic(err)
+	} else {
+		return s
+	}
+}
+
+// NewWhitespaceSplitter creates a new splitter that splits on runs of whitespace (as determined by unicode.IsSpace) -
+// i.e. the same semantics as strings.Fields but aware of enclosures
+//
+// a run of whitespace is treated as a single separator and any leading or trailing whitespace is ignored (so empty parts are never produced)
+//
+// the optional `encs` varargs are the enclosures (e.g. brackets, quotes) to be taken into consideration when splitting
+//
+// An error is returned if any of enclosures specified match any other enclosure `Start`/`End`
+func NewWhitespaceSplitter(encs ...*Enclosure) (Splitter, error) {
+	s, err := newSplitter(0, whitespaceSeparator{}, encs)
+	if err == nil {
+		s.(*splitter).skipEmpties = true
+	}
+	return s, err
+}
+
+// MustCreateWhitespaceSplitter is the same as NewWhitespaceSplitter, except that it panics in case of error
+func MustCreateWhitespaceSplitter(encs ...*Enclosure) Splitter {
+	if s, err := NewWhitespaceSplitter(encs...); err != nil {
+		panic(err)
+	} else {
+		return s
+	}
+}
+
+// NewRegexpSplitter creates a new splitter that splits on matches of a regular expression
+//
+// the `separator` arg is the regular expression on which to split (e.g. `\s*,\s*`) - only matches that begin outside of
+// any enclosure are considered as separators (and zero length matches are never considered as separators)
+//
+// the regular expression is used as supplied (so, for example, a regexp compiled with regexp.CompilePOSIX or set to
+// Longest retains that behaviour) - and, as with regexp.Regexp.Split, matches are the leftmost non-overlapping matches
+// found in the whole string (so that, for example, `^` only matches at the start of the string)
+//
+// the optional `encs` varargs are the enclosures (e.g. brackets, quotes) to be taken into consideration when splitting
+//
+// the Splitter.SplitWithSeparators method (or an option implementing SeparatorOption) can be used to determine the
+// separator text matched for each split part
+//
+// An error is returned if the separator is nil or if any of enclosures specified match any other enclosure `Start`/`End`
+func NewRegexpSplitter(separator *regexp.Regexp, encs ...*Enclosure) (Splitter, error) {
+	if separator == nil {
+		return nil, errors.New("separator regexp cannot be nil")
+	}
+	return newSplitter(0, &regexpSeparator{rx: separator}, encs)
+}
+
+// MustCreateRegexpSplitter is the same as NewRegexpSplitter, except that it panics in case of error
+func MustCreateRegexpSplitter(separator *regexp.Regexp, encs ...*Enclosure) Splitter {
+	if s, err := NewRegexpSplitter(separator, encs...); err != nil {
+		panic(err)
+	} else {
+		return s
+	}
+}
+
+// SeparatorFunc is the predicate function used by NewFuncSplitter to determine whether a rune is a separator
+//
+// the `prev` and `next` args are the runes either side of the `cur` rune being examined (or zero at the start/end of the string)
+//
+// the `depth` arg is the current enclosure nesting depth (zero when at the top level)
+type SeparatorFunc func(prev, cur, next rune, depth int) bool
+
+// NewFuncSplitter creates a new splitter that uses a predicate function to determine separators
+//
+// the `separator` arg is the function called for every rune outside of enclosures - if it returns true, the rune is a separator
+//
+// the optional `encs` varargs are the enclosures (e.g. brackets, quotes) to be taken into consideration when splitting
+//
+// An error is returned if the separator func is nil or if any of enclosures specified match any other enclosure `Start`/`End`
+func NewFuncSplitter(separator SeparatorFunc, encs ...*Enclosure) (Splitter, error) {
+	if separator == nil {
+		return nil, errors.New("separator func cannot be nil")
+	}
+	return newSplitter(0, funcSeparator(separator), encs)
+}
+
+// MustCreateFuncSplitter is the same as NewFuncSplitter, except that it panics in case of error
+func MustCreateFuncSplitter(separator SeparatorFunc, encs ...*Enclosure) Splitter {
+	if s, err := NewFuncSplitter(separator, encs...); err != nil {
+		panic(err)
+	} else {
+		return s
+	}
+}
+
+// NewKeywordSplitter creates a new splitter that splits on keywords (e.g. "AND", "OR")
+//
+// the `keywords` arg is the keywords on which to split - keywords are matched case-insensitively and only on word boundaries
+// (e.g. with a keyword of "AND", the string `a and b` would be split but `band` or `sandwich` would not)
+//
+// the optional `encs` varargs are the enclosures (e.g. brackets, quotes) to be taken into consideration when splitting
+//
+// the Splitter.SplitWithSeparators method (or an option implementing SeparatorOption) can be used to determine the
+// actual keyword occurrence that terminated each split part
+//
+// An error is returned if no keywords are specified, any keyword is empty or if any of enclosures specified match any other enclosure `Start`/`End`
+func NewKeywordSplitter(keywords []string, encs ...*Enclosure) (Splitter, error) {
+	if len(keywords) == 0 {
+		return nil, errors.New("at least one keyword must be specified")
+	}
+	sep := make(keywordSeparator, 0, len(keywords))
+	for i, kw := range keywords {
+		if kw == "" {
+			return nil, fmt.Errorf("keyword cannot be empty (keyword[%d])", i+1)
+		}
+		sep = append(sep, []rune(kw))
+	}
+	// longest keywords first...
+	sort.SliceStable(sep, func(i, j int) bool {
+		return len(sep[i]) > len(sep[j])
+	})
+	return newSplitter(0, sep, encs)
+}
+
+// MustCreateKeywordSplitter is the same as NewKeywordSplitter, except that it panics in case of error
+func MustCreateKeywordSplitter(keywords []string, encs ...*Enclosure) Splitter {
+	if s, err := NewKeywordSplitter(keywords, encs...); err != nil {
+		panic(err)
+	} else {
+		return s
+	}
+}
+
+// Separator is the interface passed to SeparatorOption.ApplySeparator - to enable examination of the separator that terminated a split part
+type Separator interface {
+	// StartPos returns the start position (relative to the original string) of the separator
+	StartPos() int
+	// EndPos returns the end position (relative to the original string) of the separator
+	EndPos() int
+	// Rune returns the (first) rune of the separator
+	Rune() rune
+	// String returns the actual raw string of the separator
+	String() string
+}
+
+type separatorPart struct {
+	start int
+	end   int
+	ctx   *splitterContext
+}
+
+func (s *separatorPart) StartPos() int {
+	return s.ctx.runePos(s.start)
+}
+
+func (s *separatorPart) EndPos() int {
+	return s.ctx.runePos(s.end) - 1
+}
+
+func (s *separatorPart) Rune() rune {
+	r, _ := utf8.DecodeRuneInString(s.ctx.str[s.start:s.end])
+	return r
+}
+
+func (s *separatorPart) String() string {
+	return s.ctx.str[s.start:s.end]
+}
+
+// separatorTexts returns the texts of a separator that is fixed text (i.e. a rune, string or set of runes) - nil for
+// other separators
+func separatorTexts(sep separatorMatcher) []string {
+	switch s := sep.(type) {
+	case runeSeparator:
+		return []string{string(s)}
+	case stringSeparator:
+		return []string{string(s)}
+	case runeSetSeparator:
+		result := make([]string, 0, len(s))
+		for r := range s {
+			result = append(result, string(r))
+		}
+		sort.Strings(result)
+		return result
+	}
+	return nil
+}
+
+// separatorMatcher is the interface used by the splitter context to determine whether there is a separator at the current position
+type separatorMatcher interface {
+	// match returns the length (in bytes) of the separator found at the current position of the context - or zero if no separator found
+	match(ctx *splitterContext) int
+}
+
+type runeSeparator rune
+
+func (s runeSeparator) match(ctx *splitterContext) int {
+	if ctx.rune == rune(s) {
+		return ctx.size
+	}
+	return 0
+}
+
+type stringSeparator string
+
+func (s stringSeparator) match(ctx *splitterContext) int {
+	if strings.HasPrefix(ctx.str[ctx.pos:ctx.len], string(s)) {
+		return len(s)
+	}
+	return 0
+}
+
+type runeSetSeparator map[rune]bool
+
+func (s runeSetSeparator) match(ctx *splitterContext) int {
+	if s[ctx.rune] {
+		return ctx.size
+	}
+	return 0
+}
+
+type whitespaceSeparator struct{}
+
+func (s whitespaceSeparator) match(ctx *splitterContext) int {
+	n := 0
+	for ctx.pos+n < ctx.len {
+		r, size := utf8.DecodeRuneInString(ctx.str[ctx.pos+n : ctx.len])
+		if !unicode.IsSpace(r) {
+			break
+		}
+		n += size
+	}
+	return n
+}
+
+type regexpSeparator struct {
+	rx         *regexp.Regexp
+	submatches bool
+}
+
+func (s *regexpSeparator) match(ctx *splitterContext) int {
+	if loc := ctx.regexpMatch(s.rx, s.submatches); loc[0] == ctx.pos && loc[1] > loc[0] {
+		return loc[1] - loc[0]
+	}
+	return 0
+}
+
+type funcSeparator SeparatorFunc
+
+func (s funcSeparator) match(ctx *splitterContext) int {
+	next := rune(0)
+	if ctx.pos+ctx.size < ctx.len {
+		next, _ = utf8.DecodeRuneInString(ctx.str[ctx.pos+ctx.size : ctx.len])
+	}
+	if s(ctx.prevRune(), ctx.rune, next, ctx.depth()) {
+		return ctx.size
+	}
+	return 0
+}
+
+type keywordSeparator [][]rune
+
+func (s keywordSeparator) match(ctx *splitterContext) int {
+	for _, kw := range s {
+		if n := s.matchKeyword(ctx, kw); n > 0 {
+			return n
+		}
+	}
+	return 0
+}
+
+func (s keywordSeparator) matchKeyword(ctx *splitterContext, kw []rune) int {
+	end := ctx.pos
+	for _, r := range kw {
+		sr, size := utf8.DecodeRuneInString(ctx.str[end:ctx.len])
+		if size == 0 || !equalFoldRune(sr, r) {
+			return 0
+		}
+		end += size
+	}
+	if isWordRune(kw[0]) && isWordRune(ctx.prevRune()) {
+		return 0
+	} else if r, size := utf8.DecodeRuneInString(ctx.str[end:ctx.len]); isWordRune(kw[len(kw)-1]) && size > 0 && isWordRune(r) {
+		return 0
+	}
+	return end - ctx.pos
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func equalFoldRune(r1, r2 rune) bool {
+	if r1 == r2 {
+		return true
+	}
+	for r := unicode.SimpleFold(r1); r != r1; r = unicode.SimpleFold(r) {
+		if r == r2 {
+			return true
+		}
+	}
+	return false
+}
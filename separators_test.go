@@ -0,0 +1,697 @@
+package splitter
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestNewStringSplitter(t *testing.T) {
+	s, err := NewStringSplitter("::")
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	rs, ok := s.(*splitter)
+	require.True(t, ok)
+	require.Equal(t, ':', rs.separator)
+	require.Equal(t, stringSeparator("::"), rs.sep)
+
+	_, err = NewStringSplitter("")
+	require.Error(t, err)
+	require.Equal(t, "separator cannot be empty", err.Error())
+
+	_, err = NewStringSplitter("::", Parenthesis, Parenthesis)
+	require.Error(t, err)
+	require.Equal(t, "existing start encloser ('(' in Enclosure[2])", err.Error())
+
+	_, err = NewStringSplitter("//", DoubleSlashLineComments)
+	require.Error(t, err)
+	require.Equal(t, "separator '//' starts encloser ('//' in Enclosure[1])", err.Error())
+	_, err = NewStringSplitter("/", BlockComments)
+	require.Error(t, err)
+	_, err = NewStringSplitter("//", BlockComments)
+	require.NoError(t, err)
+}
+
+func TestMustCreateStringSplitter_Panics(t *testing.T) {
+	require.NotPanics(t, func() {
+		MustCreateStringSplitter("::")
+	})
+	require.Panics(t, func() {
+		MustCreateStringSplitter("")
+	})
+}
+
+func TestStringSplitter_Split(t *testing.T) {
+	s, err := NewStringSplitter("::", DoubleQuotesBackSlashEscaped, Parenthesis, SquareBrackets)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str    string
+		expect []string
+	}{
+		{
+			`a::b::c`,
+			[]string{`a`, `b`, `c`},
+		},
+		{
+			`::a::`,
+			[]string{``, `a`, ``},
+		},
+		{
+			`a:b:::c`,
+			[]string{`a:b`, `:c`},
+		},
+		{
+			`a::"b::c"::(d::e)::[f::"]"]`,
+			[]string{`a`, `"b::c"`, `(d::e)`, `[f::"]"]`},
+		},
+		{
+			`a::"\"::"::b`,
+			[]string{`a`, `"\"::"`, `b`},
+		},
+		{
+			`:`,
+			[]string{`:`},
+		},
+		{
+			``,
+			[]string{``},
+		},
+		{
+			`a:`,
+			[]string{`a:`},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			result, err := s.Split(tc.str)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, result)
+		})
+	}
+}
+
+func TestStringSplitter_Split_MultiRuneSeparators(t *testing.T) {
+	testCases := []struct {
+		sep    string
+		str    string
+		expect []string
+	}{
+		{
+			"=>",
+			`a=>b,c=>"d=>e"`,
+			[]string{`a`, `b,c`, `"d=>e"`},
+		},
+		{
+			"||",
+			`a||(b||c)|d`,
+			[]string{`a`, `(b||c)|d`},
+		},
+		{
+			" AND ",
+			`a AND "b AND c" AND(d AND e)`,
+			[]string{`a`, `"b AND c" AND(d AND e)`},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			s := MustCreateStringSplitter(tc.sep, DoubleQuotes, Parenthesis)
+			result, err := s.Split(tc.str)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, result)
+		})
+	}
+}
+
+func TestStringSplitter_Split_SubParts(t *testing.T) {
+	s, err := NewStringSplitter("->", DoubleQuotes, CurlyBrackets)
+	require.NoError(t, err)
+
+	c := &infoCapture{}
+	parts, err := s.Split(`a->b "c" {d}`, c)
+	//                     012345678901
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `b "c" {d}`}, parts)
+	require.Equal(t, 2, c.called)
+	require.Equal(t, 4, c.subs)
+	require.Equal(t, []int{0, 3, 5, 8, 9}, c.startPositions)
+	require.Equal(t, []int{0, 4, 7, 8, 11}, c.endPositions)
+	require.Equal(t, []SubPartType{Fixed, Fixed, Quotes, Fixed, Brackets}, c.types)
+}
+
+func TestStringSplitter_Split_Errors(t *testing.T) {
+	s, err := NewStringSplitter("::", DoubleQuotes, CurlyBrackets)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str       string
+		expectErr string
+	}{
+		{
+			`a::}`,
+			fmt.Sprintf(unopenedFmt, "}", 3),
+		},
+		{
+			`a::{b::"c}`,
+			fmt.Sprintf(unclosedFmt, `"`, 7),
+		},
+		{
+			`a::{b::c`,
+			fmt.Sprintf(unclosedFmt, `{`, 3),
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			_, err := s.Split(tc.str)
+			require.Error(t, err)
+			require.Equal(t, tc.expectErr, err.Error())
+		})
+	}
+}
+
+func TestNewMultiSplitter(t *testing.T) {
+	s, err := NewMultiSplitter([]rune{',', ';'})
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	rs, ok := s.(*splitter)
+	require.True(t, ok)
+	require.Equal(t, runeSetSeparator{',': true, ';': true}, rs.sep)
+
+	_, err = NewMultiSplitter(nil)
+	require.Error(t, err)
+	require.Equal(t, "at least one separator must be specified", err.Error())
+
+	_, err = NewMultiSplitter([]rune{',', '#'}, DoubleQuotes, HashLineComments)
+	require.Error(t, err)
+	require.Equal(t, "separator '#' starts encloser ('#' in Enclosure[2])", err.Error())
+
+	require.NotPanics(t, func() {
+		MustCreateMultiSplitter([]rune{','})
+	})
+	require.Panics(t, func() {
+		MustCreateMultiSplitter([]rune{})
+	})
+}
+
+func TestMultiSplitter_Split(t *testing.T) {
+	s, err := NewMultiSplitter([]rune{',', ';', '|'}, DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	parts, err := s.Split(`a,b;c|d`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `b`, `c`, `d`}, parts)
+
+	parts, err = s.Split(`a,"b;c"|(d,e;f)`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `"b;c"`, `(d,e;f)`}, parts)
+
+	_, err = s.Split(`a,(b;c`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, "(", 2), err.Error())
+}
+
+func TestMultiSplitter_SplitWithSeparators(t *testing.T) {
+	s, err := NewMultiSplitter([]rune{'=', '&', ':'}, DoubleQuotes)
+	require.NoError(t, err)
+
+	parts, seps, err := s.SplitWithSeparators(`key=value&key2:"value:2"`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`key`, `value`, `key2`, `"value:2"`}, parts)
+	require.Equal(t, []string{`=`, `&`, `:`, ``}, seps)
+
+	parts, seps, err = s.SplitWithSeparators(`=a&`, IgnoreEmpties)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`}, parts)
+	require.Equal(t, []string{`&`}, seps)
+
+	_, _, err = s.SplitWithSeparators(`a="b`)
+	require.Error(t, err)
+}
+
+func TestMultiSplitter_SeparatorOption(t *testing.T) {
+	s, err := NewMultiSplitter([]rune{'=', '&'})
+	require.NoError(t, err)
+
+	c := &separatorCapture{}
+	parts, err := s.Split(`a=b&c`, c)
+	//                     01234
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `b`, `c`}, parts)
+	require.Equal(t, 3, c.called)
+	require.Equal(t, []string{`=`, `&`, ``}, c.strings)
+	require.Equal(t, []rune{'=', '&', 0}, c.runes)
+	require.Equal(t, []int{1, 3, -1}, c.startPositions)
+	require.Equal(t, []int{1, 3, -1}, c.endPositions)
+}
+
+type separatorCapture struct {
+	called         int
+	strings        []string
+	runes          []rune
+	startPositions []int
+	endPositions   []int
+}
+
+func (o *separatorCapture) Apply(s string, pos int, totalLen int, captured int, skipped int, isLast bool, subParts ...SubPart) (string, bool, error) {
+	panic("should not be called")
+}
+
+func (o *separatorCapture) ApplySeparator(s string, pos int, totalLen int, captured int, skipped int, isLast bool, sep Separator, subParts ...SubPart) (string, bool, error) {
+	o.called++
+	if sep != nil {
+		o.strings = append(o.strings, sep.String())
+		o.runes = append(o.runes, sep.Rune())
+		o.startPositions = append(o.startPositions, sep.StartPos())
+		o.endPositions = append(o.endPositions, sep.EndPos())
+	} else {
+		o.strings = append(o.strings, "")
+		o.runes = append(o.runes, 0)
+		o.startPositions = append(o.startPositions, -1)
+		o.endPositions = append(o.endPositions, -1)
+	}
+	return s, true, nil
+}
+
+func TestNewWhitespaceSplitter(t *testing.T) {
+	s, err := NewWhitespaceSplitter(DoubleQuotes)
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	rs, ok := s.(*splitter)
+	require.True(t, ok)
+	require.True(t, rs.skipEmpties)
+	require.Equal(t, whitespaceSeparator{}, rs.sep)
+
+	_, err = NewWhitespaceSplitter(DoubleQuotes, DoubleQuotes)
+	require.Error(t, err)
+
+	require.NotPanics(t, func() {
+		MustCreateWhitespaceSplitter(DoubleQuotes)
+	})
+	require.Panics(t, func() {
+		MustCreateWhitespaceSplitter(DoubleQuotes, DoubleQuotes)
+	})
+}
+
+func TestWhitespaceSplitter_Split(t *testing.T) {
+	s, err := NewWhitespaceSplitter(DoubleQuotes, SingleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str    string
+		expect []string
+	}{
+		{
+			``,
+			[]string{},
+		},
+		{
+			" \t\n ",
+			[]string{},
+		},
+		{
+			`a`,
+			[]string{`a`},
+		},
+		{
+			" a  b\tc\n\nd ",
+			[]string{`a`, `b`, `c`, `d`},
+		},
+		{
+			"a b c",
+			[]string{`a`, `b`, `c`},
+		},
+		{
+			`cmd "arg one" 'arg  two' (x y)`,
+			[]string{`cmd`, `"arg one"`, `'arg  two'`, `(x y)`},
+		},
+		{
+			`  a"b c"d  `,
+			[]string{`a"b c"d`},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			result, err := s.Split(tc.str)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, result)
+		})
+	}
+}
+
+func TestWhitespaceSplitter_Split_PositionsAndErrors(t *testing.T) {
+	s, err := NewWhitespaceSplitter(DoubleQuotes)
+	require.NoError(t, err)
+
+	c := &infoCapture{}
+	parts, err := s.Split("  a \t\"b c\"  ", c)
+	//                     01234 5678 9
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `"b c"`}, parts)
+	require.Equal(t, 2, c.called)
+	require.Equal(t, []int{2, 5}, c.startPositions)
+	require.Equal(t, []int{2, 9}, c.endPositions)
+
+	parts, seps, err := s.SplitWithSeparators(" a \t b ")
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `b`}, parts)
+	require.Equal(t, []string{" \t ", " "}, seps)
+
+	_, err = s.Split(`a "b c`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `"`, 2), err.Error())
+}
+
+func TestNewRegexpSplitter(t *testing.T) {
+	s, err := NewRegexpSplitter(regexp.MustCompile(`\s*,\s*`))
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	rs, ok := s.(*splitter)
+	require.True(t, ok)
+	rxs, ok := rs.sep.(*regexpSeparator)
+	require.True(t, ok)
+	require.Equal(t, `\s*,\s*`, rxs.rx.String())
+
+	_, err = NewRegexpSplitter(nil)
+	require.Error(t, err)
+	require.Equal(t, "separator regexp cannot be nil", err.Error())
+
+	require.NotPanics(t, func() {
+		MustCreateRegexpSplitter(regexp.MustCompile(`,`))
+	})
+	require.Panics(t, func() {
+		MustCreateRegexpSplitter(nil)
+	})
+}
+
+func TestRegexpSplitter_Split(t *testing.T) {
+	testCases := []struct {
+		rx     string
+		str    string
+		expect []string
+		seps   []string
+	}{
+		{
+			`\s*,\s*`,
+			`a , b,c ,"d , e"`,
+			[]string{`a`, `b`, `c`, `"d , e"`},
+			[]string{` , `, `,`, ` ,`, ``},
+		},
+		{
+			`\s+(?:and|or)\s+`,
+			`a and b or (c and d) and "e or f"`,
+			[]string{`a`, `b`, `(c and d)`, `"e or f"`},
+			[]string{` and `, ` or `, ` and `, ``},
+		},
+		{
+			`;\n?`,
+			"a;\nb;c;\n",
+			[]string{`a`, `b`, `c`, ``},
+			[]string{";\n", `;`, ";\n", ``},
+		},
+		{
+			`\s*`,
+			`a b`,
+			[]string{`a`, `b`},
+			[]string{` `, ``},
+		},
+		{
+			`,`,
+			`äö,"ü,ß",é`,
+			[]string{`äö`, `"ü,ß"`, `é`},
+			[]string{`,`, `,`, ``},
+		},
+		{
+			`x+`,
+			`axxb(xx)"xx"xc`,
+			[]string{`a`, `b(xx)"xx"`, `c`},
+			[]string{`xx`, `x`, ``},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			s := MustCreateRegexpSplitter(regexp.MustCompile(tc.rx), DoubleQuotes, Parenthesis)
+			parts, seps, err := s.SplitWithSeparators(tc.str)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, parts)
+			require.Equal(t, tc.seps, seps)
+		})
+	}
+}
+
+func TestRegexpSplitter_Split_RegexpSettings(t *testing.T) {
+	s := MustCreateRegexpSplitter(regexp.MustCompile(`a|ab`))
+	parts, err := s.Split(`1ab2`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`1`, `b2`}, parts)
+
+	rx := regexp.MustCompile(`a|ab`)
+	rx.Longest()
+	s = MustCreateRegexpSplitter(rx)
+	parts, err = s.Split(`1ab2`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`1`, `2`}, parts)
+
+	s = MustCreateRegexpSplitter(regexp.MustCompilePOSIX(`a|ab`))
+	parts, err = s.Split(`1ab2`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`1`, `2`}, parts)
+
+	// matches within enclosures are not separators...
+	s = MustCreateRegexpSplitter(regexp.MustCompile(`\s*,\s*`), DoubleQuotes)
+	parts, err = s.Split(`a , "b , c" , d`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `"b , c"`, `d`}, parts)
+}
+
+func TestRegexpSplitter_Split_SameAsRegexpSplit(t *testing.T) {
+	testCases := []struct {
+		rx  string
+		str string
+	}{
+		{`^x`, `xxxb`},
+		{`\bx`, `x,xx`},
+		{`x\b`, `ax,x xa`},
+		{`\Bx`, `xx,axx`},
+		{`(?m)^#`, "#a\n#b#c\n#"},
+		{`\s*,\s*`, ` a , b,c ,`},
+		{`a|ab`, `1ab2a`},
+		{`\bx`, strings.Repeat("xa,", 100)},
+		{`,`, strings.Repeat("a,", 100)},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.rx), func(t *testing.T) {
+			rx := regexp.MustCompile(tc.rx)
+			parts, err := MustCreateRegexpSplitter(rx).Split(tc.str)
+			require.NoError(t, err)
+			require.Equal(t, rx.Split(tc.str, -1), parts)
+		})
+	}
+
+	// a match passed over (starting within an enclosure) that extends beyond the enclosure...
+	s := MustCreateRegexpSplitter(regexp.MustCompile(`"?,`), DoubleQuotes)
+	parts, err := s.Split(`"a,b",c`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`"a,b"`, `c`}, parts)
+}
+
+func TestRegexpSplitter_Split_PositionsAndErrors(t *testing.T) {
+	s, err := NewRegexpSplitter(regexp.MustCompile(`\s*;\s*`), DoubleQuotes)
+	require.NoError(t, err)
+
+	c := &separatorCapture{}
+	parts, err := s.Split(`ü ; "b;c"`, c)
+	//                     012345678
+	require.NoError(t, err)
+	require.Equal(t, []string{`ü`, `"b;c"`}, parts)
+	require.Equal(t, []int{1, -1}, c.startPositions)
+	require.Equal(t, []int{3, -1}, c.endPositions)
+
+	_, err = s.Split(`a ; "b;c`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `"`, 4), err.Error())
+}
+
+func TestNewFuncSplitter(t *testing.T) {
+	s, err := NewFuncSplitter(func(prev, cur, next rune, depth int) bool {
+		return cur == ','
+	})
+	require.NoError(t, err)
+	require.NotNil(t, s)
+
+	_, err = NewFuncSplitter(nil)
+	require.Error(t, err)
+	require.Equal(t, "separator func cannot be nil", err.Error())
+
+	require.NotPanics(t, func() {
+		MustCreateFuncSplitter(func(prev, cur, next rune, depth int) bool {
+			return false
+		})
+	})
+	require.Panics(t, func() {
+		MustCreateFuncSplitter(nil)
+	})
+}
+
+func TestFuncSplitter_Split(t *testing.T) {
+	isDigit := func(r rune) bool {
+		return r >= '0' && r <= '9'
+	}
+	testCases := []struct {
+		fn     SeparatorFunc
+		str    string
+		expect []string
+	}{
+		{
+			func(prev, cur, next rune, depth int) bool {
+				return cur == ',' && !(isDigit(prev) && isDigit(next))
+			},
+			`1,000,a,2,500,"b,c"`,
+			[]string{`1,000`, `a`, `2,500`, `"b,c"`},
+		},
+		{
+			func(prev, cur, next rune, depth int) bool {
+				return cur == '.' && !(isDigit(prev) && isDigit(next))
+			},
+			`a.1.5.b.(c.d)`,
+			[]string{`a`, `1.5`, `b`, `(c.d)`},
+		},
+		{
+			func(prev, cur, next rune, depth int) bool {
+				return cur == '-' && prev == ' ' && next == ' '
+			},
+			`a - b-c -d - "e - f"`,
+			[]string{`a `, ` b-c -d `, ` "e - f"`},
+		},
+		{
+			func(prev, cur, next rune, depth int) bool {
+				return cur == ','
+			},
+			`,`,
+			[]string{``, ``},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			s := MustCreateFuncSplitter(tc.fn, DoubleQuotes, Parenthesis)
+			result, err := s.Split(tc.str)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, result)
+		})
+	}
+}
+
+func TestFuncSplitter_Split_CalledOutsideEnclosures(t *testing.T) {
+	called := make([]rune, 0)
+	depths := make([]int, 0)
+	s, err := NewFuncSplitter(func(prev, cur, next rune, depth int) bool {
+		called = append(called, cur)
+		depths = append(depths, depth)
+		return cur == '|'
+	}, DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	parts, err := s.Split(`a|(b|c)|"d|e"`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `(b|c)`, `"d|e"`}, parts)
+	require.Equal(t, []rune{'a', '|', '(', '|', '"'}, called)
+	require.Equal(t, []int{0, 0, 0, 0, 0}, depths)
+
+	_, err = s.Split(`a|(b|c`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `(`, 2), err.Error())
+}
+
+func TestNewKeywordSplitter(t *testing.T) {
+	s, err := NewKeywordSplitter([]string{"OR", "AND"})
+	require.NoError(t, err)
+	require.NotNil(t, s)
+	rs, ok := s.(*splitter)
+	require.True(t, ok)
+	require.Equal(t, keywordSeparator{[]rune("AND"), []rune("OR")}, rs.sep)
+
+	_, err = NewKeywordSplitter(nil)
+	require.Error(t, err)
+	require.Equal(t, "at least one keyword must be specified", err.Error())
+
+	_, err = NewKeywordSplitter([]string{"AND", ""})
+	require.Error(t, err)
+	require.Equal(t, "keyword cannot be empty (keyword[2])", err.Error())
+
+	require.NotPanics(t, func() {
+		MustCreateKeywordSplitter([]string{"AND"})
+	})
+	require.Panics(t, func() {
+		MustCreateKeywordSplitter([]string{})
+	})
+}
+
+func TestKeywordSplitter_Split(t *testing.T) {
+	s, err := NewKeywordSplitter([]string{"AND", "OR"}, SingleQuotesDoubleEscaped, Parenthesis)
+	require.NoError(t, err)
+
+	parts, keywords, err := s.SplitWithSeparators(`status = 'open' AND owner IN ('a and b', 'c') or priority > 2`, TrimSpaces)
+	require.NoError(t, err)
+	require.Equal(t, []string{`status = 'open'`, `owner IN ('a and b', 'c')`, `priority > 2`}, parts)
+	require.Equal(t, []string{`AND`, `or`, ``}, keywords)
+
+	testCases := []struct {
+		str    string
+		expect []string
+	}{
+		{
+			`a and b`,
+			[]string{`a `, ` b`},
+		},
+		{
+			`band or sandwich`,
+			[]string{`band `, ` sandwich`},
+		},
+		{
+			`android ORACLE _and and_ and1`,
+			[]string{`android ORACLE _and and_ and1`},
+		},
+		{
+			`AND`,
+			[]string{``, ``},
+		},
+		{
+			`x=1 And(y=2)oR'z'`,
+			[]string{`x=1 `, `(y=2)`, `'z'`},
+		},
+		{
+			`a an`,
+			[]string{`a an`},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			result, err := s.Split(tc.str)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, result)
+		})
+	}
+
+	_, err = s.Split(`a and (b or c`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `(`, 6), err.Error())
+}
+
+func TestKeywordSplitter_Split_LongestFirst(t *testing.T) {
+	s, err := NewKeywordSplitter([]string{"&", "&&", "AND NOT", "AND"})
+	require.NoError(t, err)
+
+	parts, keywords, err := s.SplitWithSeparators(`a&&b&c and not d and e`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `b`, `c `, ` d `, ` e`}, parts)
+	require.Equal(t, []string{`&&`, `&`, `and not`, `and`, ``}, keywords)
+}
+
+func TestEqualFoldRune(t *testing.T) {
+	require.True(t, equalFoldRune('a', 'a'))
+	require.True(t, equalFoldRune('a', 'A'))
+	require.True(t, equalFoldRune('K', 'K')) // Kelvin sign
+	require.True(t, equalFoldRune('ß', 'ẞ'))
+	require.False(t, equalFoldRune('a', 'b'))
+}
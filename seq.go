@@ -0,0 +1,22 @@
+//go:build go1.23
+
+package splitter
+
+import "iter"
+
+// Seq returns an iterator over the parts of the supplied string split by the supplied Splitter
+//
+// Parts are yielded as they are found (with a nil error) - if an error is encountered (e.g. unbalanced enclosures),
+// it is yielded (with a zero Part) and iteration ends
+func Seq(s Splitter, str string, options ...Option) iter.Seq2[Part, error] {
+	return func(yield func(Part, error) bool) {
+		stopped := false
+		err := s.Each(str, func(part Part) bool {
+			stopped = !yield(part, nil)
+			return !stopped
+		}, options...)
+		if err != nil && !stopped {
+			yield(Part{}, err)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+//go:build go1.23
+
+package splitter
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSeq(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes)
+	require.NoError(t, err)
+
+	values := make([]string, 0)
+	for pt, err := range Seq(s, `a,"b,c",d`) {
+		require.NoError(t, err)
+		values = append(values, pt.Value)
+	}
+	require.Equal(t, []string{`a`, `"b,c"`, `d`}, values)
+
+	values = make([]string, 0)
+	for pt, err := range Seq(s, `a,b,"c`) {
+		if err != nil {
+			require.Equal(t, fmt.Sprintf(unclosedFmt, `"`, 4), err.Error())
+			break
+		}
+		values = append(values, pt.Value)
+	}
+	require.Equal(t, []string{`a`, `b`}, values)
+
+	values = make([]string, 0)
+	for pt, err := range Seq(s, `a,b,"c`) {
+		require.NoError(t, err)
+		values = append(values, pt.Value)
+		break
+	}
+	require.Equal(t, []string{`a`}, values)
+}
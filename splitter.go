@@ -2,6 +2,12 @@ package splitter
 
 import (
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+	"unsafe"
 )
 
 // Splitter is the actual splitter interface
@@ -10,65 +16,381 @@ type Splitter interface {
 	//
 	// If an error is returned, it will always be of type splittingError
 	Split(s string, options ...Option) ([]string, error)
+	// SplitWithSeparators is the same as Split, except that it also returns the separators that terminated each split part
+	//
+	// The returned separators always correspond to the returned parts (i.e. separators[n] is the separator that terminated parts[n]) -
+	// the separator for a part terminated by the end of the string is an empty string
+	SplitWithSeparators(s string, options ...Option) ([]string, []string, error)
 	// AddDefaultOptions adds default options for the splitter (other options can also be added when using Split)
 	AddDefaultOptions(options ...Option) Splitter
+	// SetSeparatorEscape sets the escape rune for separators - a separator (outside of enclosures) that is prefixed with the
+	// escape rune is not treated as a separator (e.g. with an escape of '\\', `a\,b,c` splits into `a\,b` and `c`)
+	//
+	// Use the UnescapeSeparators option to remove the escape runes from split parts
+	//
+	// Setting an escape of zero removes separator escaping
+	SetSeparatorEscape(escape rune) Splitter
+	// SetCommentPolicy sets what happens to comments (enclosures with IsComment) found when splitting - the default
+	// policy is CommentsStrip
+	SetCommentPolicy(policy CommentPolicy) Splitter
+	// SplitEnclosed performs a split on the contents of the enclosure that is the supplied string - returns the split parts and any error encountered
+	//
+	// The supplied string must be exactly one enclosure (e.g. `[a, "b,c", (d,e)]`) - only separators directly within that enclosure
+	// are split on (i.e. separators in nested enclosures are not considered)
+	//
+	// If an error is returned, it will always be of type SplittingError (and will be of type NotEnclosed if the
+	// supplied string is not exactly one enclosure)
+	SplitEnclosed(s string, options ...Option) ([]string, error)
+	// SplitAfter is the same as Split, except that the separators are retained at the end of each split part (i.e. the same as strings.SplitAfter)
+	//
+	// Any options are applied to the split parts including the separator
+	SplitAfter(s string, options ...Option) ([]string, error)
+	// SplitKeepSeparators is the same as Split, except that the separators are retained as parts in the result
+	// (i.e. the result is the split parts interleaved with the separators)
+	//
+	// Any options are applied only to the split parts (the separators are always added to the result as-is) - if an option
+	// causes a part not to be added, the separator preceding it is also not added (so the result never starts or ends with
+	// a separator and never has two separators in a row)
+	SplitKeepSeparators(s string, options ...Option) ([]string, error)
+	// SplitN is the same as Split, except that the number of split parts is limited - the `n` arg determines the number of parts...
+	//
+	// n > 0: at most n parts (the last part being the unsplit remainder) - parts not added to the result (e.g. by the
+	// IgnoreEmpties option) do not count towards n
+	//
+	// n == 0: the result is nil (zero parts)
+	//
+	// n < 0: all parts (i.e. the same as Split)
+	//
+	// Any options are applied to every split part (including the unsplit remainder)
+	SplitN(s string, n int, options ...Option) ([]string, error)
+	// SplitParts is the same as Split, except that it returns rich parts - each part having the raw (before options were applied)
+	// and final text, the position spans (in runes, bytes and UTF-16 code units) within the original string and the sub-parts
+	//
+	// If an error is returned, it will always be of type SplittingError
+	SplitParts(s string, options ...Option) ([]Part, error)
+	// Each performs a split on the supplied string - calling the supplied `fn` for each part as it is found
+	//
+	// If the `fn` returns false, splitting is stopped (and no further parts are found) - any error encountered
+	// (e.g. unbalanced enclosures) before then is returned
+	//
+	// If an error is returned, it will always be of type SplittingError
+	Each(s string, fn func(Part) bool, options ...Option) error
+	// RSplitN is the same as SplitN, except that the splitting is from the right (i.e. the first part being the unsplit remainder)
+	RSplitN(s string, n int, options ...Option) ([]string, error)
+	// SplitBytes is the same as Split, except that it splits the supplied UTF-8 encoded bytes
+	//
+	// The bytes are split without being copied - where a split part has not been modified by any option, the returned
+	// part is a sub-slice of the supplied bytes (otherwise the returned part is a copy)
+	//
+	// Note: the strings passed to any options share the memory of the supplied bytes (and so must not be retained by options)
+	//
+	// If an error is returned, it will always be of type SplittingError
+	SplitBytes(b []byte, options ...Option) ([][]byte, error)
+	// IndexOutside returns the position (in runes, bytes and UTF-16 code units) of the first occurrence of substr
+	// that is outside of any enclosures - or NotFound if substr is not present outside of enclosures
+	//
+	// An error is returned if substr is empty or the string has unbalanced enclosures
+	IndexOutside(s string, substr string) (Position, error)
+	// LastIndexOutside is the same as IndexOutside, except that it returns the position of the last occurrence
+	LastIndexOutside(s string, substr string) (Position, error)
+	// ContainsOutside reports whether substr occurs outside of any enclosures
+	//
+	// An error is returned if substr is empty or the string has unbalanced enclosures
+	ContainsOutside(s string, substr string) (bool, error)
+	// CountOutside returns the number of non-overlapping occurrences of substr outside of any enclosures
+	//
+	// An error is returned if substr is empty or the string has unbalanced enclosures
+	CountOutside(s string, substr string) (int, error)
+	// IndexAnyOutside returns the position (in runes, bytes and UTF-16 code units) of the first occurrence of any of the
+	// supplied runes that is outside of any enclosures - or NotFound if none are present outside of enclosures
+	//
+	// An error is returned if the string has unbalanced enclosures
+	IndexAnyOutside(s string, runes ...rune) (Position, error)
+	// Cut slices the string around the first separator (outside of any enclosures) - returning the text before and after
+	// the separator (i.e. the same as strings.Cut, but enclosure aware)
+	//
+	// If no separator is found, Cut returns the string (with options applied) as before, an empty after and found as false
+	//
+	// Any options are applied to both before and after (an option that would ignore a part results in an empty string)
+	//
+	// If an error is returned, it will always be of type SplittingError
+	Cut(s string, options ...Option) (before string, after string, found bool, err error)
+	// CutLast is the same as Cut, except that the string is sliced around the last separator
+	CutLast(s string, options ...Option) (before string, after string, found bool, err error)
+	// ReplaceSeparators returns a copy of the string with every separator (outside of any enclosures) replaced by new
+	//
+	// Separators are found in exactly the same way as Split (i.e. escaped separators are not replaced) - all other text,
+	// including enclosures, is left untouched
+	//
+	// If an error is returned, it will always be of type SplittingError
+	ReplaceSeparators(s string, new string) (string, error)
+	// ReplaceOutside returns a copy of the string with the non-overlapping occurrences of old that are outside of any
+	// enclosures replaced by new
+	//
+	// Occurrences prefixed by the separator escape (see SetSeparatorEscape) are not replaced
+	//
+	// An error is returned if old is empty or the string has unbalanced enclosures
+	ReplaceOutside(s string, old string, new string) (string, error)
+	// ReplaceRegexpOutside is the same as ReplaceOutside, except that matches of the regexp are replaced - the replacement
+	// string may contain submatch references (see regexp.Regexp.Expand)
+	//
+	// An error is returned if the regexp is nil or the string has unbalanced enclosures
+	ReplaceRegexpOutside(s string, rx *regexp.Regexp, repl string) (string, error)
+	// Validate checks that the enclosures in the string are balanced (without splitting) - returning every problem found
+	// (an empty slice if there are no problems)
+	//
+	// The problems reported are all unopened closers (Unopened), all unclosed openers (Unclosed), any closers
+	// that close an enclosure other than the innermost open enclosure (Mismatched) and any openers that break the
+	// nesting rules of the enclosures (NestingViolation) - in order of position
+	Validate(s string) []SplittingError
+	// FieldsOutside splits the string around runs of whitespace that are outside of any enclosures
+	// (i.e. the same as strings.Fields, but enclosure aware)
+	FieldsOutside(s string, options ...Option) ([]string, error)
 }
 
+// CommentPolicy determines what happens to comments found when splitting (see Splitter.SetCommentPolicy)
+type CommentPolicy int
+
+const (
+	// CommentsStrip causes comments to be removed from split parts - options are not passed the comment sub-parts
+	// (only comments directly within a part are removed - comments nested within brackets are retained, so that the
+	// text of a part is always the same as the text of its sub-parts)
+	CommentsStrip CommentPolicy = iota
+	// CommentsKeep causes comments to be retained in split parts - options are passed the comment sub-parts (see SubPart.IsComment)
+	CommentsKeep
+	// CommentsError causes an error (of type CommentFound) if a comment is found
+	CommentsError
+)
+
 // NewSplitter creates a new splitter
 //
 // the `separator` arg is the rune on which to split
 //
 // the optional `encs` varargs are the enclosures (e.g. brackets, quotes) to be taken into consideration when splitting
 //
-// An error is returned if any of enclosures specified match any other enclosure `Start`/`End`
+// An error is returned if any of enclosures specified match any other enclosure start/end - enclosures whose
+// starts (or ends) merely overlap (e.g. `"` and `"""`) are permitted, the longest being matched first (dynamic enclosures,
+// those with a Matcher, are only checked by their Start - and prefixed variants of enclosures may share the same end)
+//
+// An error is also returned if the separator is (or is the start of) the start of any of the enclosures (e.g. a separator
+// of '/' with BlockComments)
 func NewSplitter(separator rune, encs ...*Enclosure) (Splitter, error) {
+	return newSplitter(separator, runeSeparator(separator), encs)
+}
+
+// MustCreateSplitter is the same as NewSplitter, except that it panics in case of error
+func MustCreateSplitter(separator rune, encs ...*Enclosure) Splitter {
+	if s, err := NewSplitter(separator, encs...); err != nil {
+		panic(err)
+	} else {
+		return s
+	}
+}
+
+func newSplitter(separator rune, sep separatorMatcher, encs []*Enclosure) (Splitter, error) {
 	result := &splitter{
 		separator:   separator,
+		sep:         sep,
 		enclosures:  make([]Enclosure, 0, len(encs)),
-		openers:     map[rune]Enclosure{},
-		closers:     map[rune]Enclosure{},
+		openers:     map[rune][]Enclosure{},
+		closers:     map[rune][]Enclosure{},
 		defOptions:  make([]Option, 0),
 		seenOptions: map[Option]bool{},
 	}
+	seps := separatorTexts(sep)
 	for i, enc := range encs {
 		if enc != nil {
-			if _, exists := result.openers[enc.Start]; exists {
-				return nil, fmt.Errorf("existing start encloser ('%s' in Enclosure[%d])", string(enc.Start), i+1)
+			for _, s := range seps {
+				if enc.startsWith(s) {
+					return nil, fmt.Errorf("separator '%s' starts encloser ('%s' in Enclosure[%d])", s, enc.Prefix+enc.startSeq(), i+1)
+				}
 			}
-			if _, exists := result.closers[enc.End]; exists {
-				return nil, fmt.Errorf("existing end encloser ('%s' in Enclosure[%d])", string(enc.End), i+1)
+			for _, other := range result.enclosures {
+				if other.sameStart(enc) {
+					return nil, fmt.Errorf("existing start encloser ('%s' in Enclosure[%d])", enc.Prefix+enc.startSeq(), i+1)
+				} else if enc.hasEnd() && other.hasEnd() && enc.Prefix == "" && other.Prefix == "" && other.endSeq() == enc.endSeq() {
+					return nil, fmt.Errorf("existing end encloser ('%s' in Enclosure[%d])", enc.endSeq(), i+1)
+				}
 			}
 			cEnc := enc.clone()
-			result.openers[enc.Start] = cEnc
-			result.closers[enc.End] = cEnc
+			for _, r := range cEnc.openRunes() {
+				result.openers[r] = insertLongestFirst(result.openers[r], cEnc, (*Enclosure).startLen)
+			}
+			if cEnc.hasEnd() {
+				// line comments end at the end of line and dynamic enclosure ends are only known once started
+				// (neither of which are treated as closers)...
+				result.closers[cEnc.endRune()] = insertLongestFirst(result.closers[cEnc.endRune()], cEnc, (*Enclosure).endLen)
+			}
 			result.enclosures = append(result.enclosures, *enc)
 		}
 	}
 	return result, nil
 }
 
-// MustCreateSplitter is the same as NewSplitter, except that it panics in case of error
-func MustCreateSplitter(separator rune, encs ...*Enclosure) Splitter {
-	if s, err := NewSplitter(separator, encs...); err != nil {
-		panic(err)
-	} else {
-		return s
+// insertLongestFirst inserts the enclosure into the list of enclosures (that share the same first start/end rune) - keeping
+// the list ordered by longest start/end first (so that, for example, a start of `"""` is matched in preference to `"`)
+func insertLongestFirst(encs []Enclosure, enc Enclosure, length func(*Enclosure) int) []Enclosure {
+	l := length(&enc)
+	i := 0
+	for i < len(encs) && length(&encs[i]) >= l {
+		i++
 	}
+	encs = append(encs, Enclosure{})
+	copy(encs[i+1:], encs[i:])
+	encs[i] = enc
+	return encs
 }
 
 type splitter struct {
-	separator   rune
-	enclosures  []Enclosure
-	openers     map[rune]Enclosure
-	closers     map[rune]Enclosure
-	defOptions  []Option
-	seenOptions map[Option]bool
+	separator     rune
+	sep           separatorMatcher
+	skipEmpties   bool
+	sepEscape     rune
+	commentPolicy CommentPolicy
+	enclosures    []Enclosure
+	openers       map[rune][]Enclosure
+	closers       map[rune][]Enclosure
+	defOptions    []Option
+	seenOptions   map[Option]bool
 }
 
 func (s *splitter) Split(str string, options ...Option) ([]string, error) {
 	return newSplitterContext(str, s, s.mergeOptions(options)).split()
 }
 
+func (s *splitter) SplitBytes(b []byte, options ...Option) ([][]byte, error) {
+	ctx := newBytesSplitterContext(b, s, s.mergeOptions(options))
+	if _, err := ctx.split(); err != nil {
+		return nil, err
+	}
+	return ctx.byteParts, nil
+}
+
+func (s *splitter) SplitWithSeparators(str string, options ...Option) ([]string, []string, error) {
+	ctx := newSplitterContext(str, s, s.mergeOptions(options))
+	ctx.separators = make([]string, 0, cap(ctx.captured))
+	if parts, err := ctx.split(); err != nil {
+		return nil, nil, err
+	} else {
+		return parts, ctx.separators, nil
+	}
+}
+
+func (s *splitter) SplitEnclosed(str string, options ...Option) ([]string, error) {
+	ctx := newSplitterContext(str, s, s.mergeOptions(options))
+	if ctx.len == 0 {
+		return nil, newSplittingError(NotEnclosed, 0, 0, nil)
+	}
+	ctx.rune, ctx.size = utf8.DecodeRuneInString(str)
+	enc, n := ctx.isOpener()
+	if enc == nil {
+		return nil, newSplittingError(NotEnclosed, 0, ctx.rune, nil)
+	}
+	ctx.splitDepth = 1
+	ctx.lastAt = n
+	ctx.trail = enc.endLen()
+	return ctx.split()
+}
+
+func (s *splitter) SplitAfter(str string, options ...Option) ([]string, error) {
+	ctx := newSplitterContext(str, s, s.mergeOptions(options))
+	ctx.sepMode = separatorsAfter
+	return ctx.split()
+}
+
+func (s *splitter) SplitKeepSeparators(str string, options ...Option) ([]string, error) {
+	ctx := newSplitterContext(str, s, s.mergeOptions(options))
+	ctx.sepMode = separatorsKept
+	return ctx.split()
+}
+
+func (s *splitter) SplitParts(str string, options ...Option) ([]Part, error) {
+	ctx := newSplitterContext(str, s, s.mergeOptions(options))
+	ctx.parts = make([]Part, 0, cap(ctx.captured))
+	if _, err := ctx.split(); err != nil {
+		return nil, err
+	}
+	return ctx.parts, nil
+}
+
+func (s *splitter) Each(str string, fn func(Part) bool, options ...Option) error {
+	ctx := newLazySplitterContext(str, 0, len(str), s, s.mergeOptions(options))
+	ctx.yield = fn
+	_, err := ctx.split()
+	return err
+}
+
+func (s *splitter) SplitN(str string, n int, options ...Option) ([]string, error) {
+	if n == 0 {
+		return nil, nil
+	}
+	ctx := newSplitterContext(str, s, s.mergeOptions(options))
+	if n > 0 {
+		ctx.maxSplits = n - 1
+	}
+	return ctx.split()
+}
+
+func (s *splitter) RSplitN(str string, n int, options ...Option) ([]string, error) {
+	if n == 0 {
+		return nil, nil
+	} else if n < 0 {
+		return s.Split(str, options...)
+	}
+	// find which parts are added (only those count from the right)...
+	options = s.mergeOptions(options)
+	pctx := newSplitterContext(str, s, options)
+	pctx.spans, pctx.probing = make([]span, 0, cap(pctx.captured)), true
+	if _, err := pctx.split(); err != nil {
+		return nil, err
+	}
+	ctx := newSplitterContext(str, s, options)
+	if l := len(pctx.spans); l > n {
+		// ignore separators within the remainder...
+		ctx.ignoreFrom, ctx.ignoreTo = pctx.spans[0].start, pctx.spans[l-n].end
+	}
+	return ctx.split()
+}
+
+func (s *splitter) Cut(str string, options ...Option) (before string, after string, found bool, err error) {
+	ctx := newLazySplitterContext(str, 0, len(str), s, s.mergeOptions(options))
+	return ctx.cut()
+}
+
+func (s *splitter) CutLast(str string, options ...Option) (before string, after string, found bool, err error) {
+	pctx := newSplitterContext(str, s, nil)
+	pctx.spans = make([]span, 0, cap(pctx.captured))
+	if _, err = pctx.split(); err != nil {
+		return "", "", false, err
+	}
+	ctx := newLazySplitterContext(str, 0, len(str), s, s.mergeOptions(options))
+	if l := len(pctx.spans); l > 2 {
+		// ignore all but the last separator...
+		ctx.ignoreFrom, ctx.ignoreTo = pctx.spans[0].start, pctx.spans[l-2].end
+	}
+	return ctx.cut()
+}
+
+// cut splits at the first separator found (whether or not the part before it is added) - returning the parts before and after
+func (ctx *splitterContext) cut() (before string, after string, found bool, err error) {
+	ctx.maxSplits, ctx.cutting = 1, true
+	ctx.yield = func(part Part) bool {
+		if part.Separator != "" {
+			before = part.Value
+		} else {
+			after = part.Value
+		}
+		return true
+	}
+	if _, err = ctx.split(); err != nil {
+		return "", "", false, err
+	}
+	if found = ctx.splits > 0; !found {
+		before, after = after, ""
+	}
+	return
+}
+
 func (s *splitter) AddDefaultOptions(options ...Option) Splitter {
 	for _, opt := range options {
 		if opt != nil && !s.seenOptions[opt] {
@@ -79,6 +401,16 @@ func (s *splitter) AddDefaultOptions(options ...Option) Splitter {
 	return s
 }
 
+func (s *splitter) SetSeparatorEscape(escape rune) Splitter {
+	s.sepEscape = escape
+	return s
+}
+
+func (s *splitter) SetCommentPolicy(policy CommentPolicy) Splitter {
+	s.commentPolicy = policy
+	return s
+}
+
 func (s *splitter) mergeOptions(addOpts []Option) []Option {
 	addLen := len(addOpts)
 	defLen := len(s.defOptions)
@@ -107,93 +439,236 @@ func (s *splitter) mergeOptions(addOpts []Option) []Option {
 }
 
 type splitterContext struct {
-	splitter *splitter
-	options  []Option
-	runes    []rune
-	pos      int
-	rune     rune
-	len      int
-	lastAt   int
-	current  *subPart
-	stack    []*subPart
-	delims   []SubPart
-	captured []string
-	skipped  int
+	splitter     *splitter
+	options      []Option
+	str          string
+	start        int
+	splitDepth   int
+	pos          int
+	rune         rune
+	size         int
+	len          int
+	lastAt       int
+	current      *subPart
+	stack        []*subPart
+	delims       []SubPart
+	captured     []string
+	separators   []string
+	spans        []span
+	parts        []Part
+	runeCounter  counter
+	utf16Counter counter
+	runeLen      int
+	escapes      []int
+	value        []span
+	rxMatches    [][]int
+	rxFrom       int
+	rxFound      int
+	sepMode      separatorMode
+	keptSep      string
+	count        int
+	yield        func(Part) bool
+	stopped      bool
+	maxSplits    int
+	splits       int
+	cutting      bool
+	probing      bool
+	ignoreFrom   int
+	ignoreTo     int
+	skipped      int
+	trail        int
+	comments     []span
+	hereDocs     []pendingHereDoc
+	partial      bool
+	lookahead    int
+	input        []byte
+	byteParts    [][]byte
+}
+
+// separatorMode determines what happens to separators in the split result
+type separatorMode int
+
+const (
+	separatorsDiscarded separatorMode = iota
+	separatorsAfter
+	separatorsKept
+)
+
+// span is the start (inclusive) and end (exclusive) byte positions of a captured part
+type span struct {
+	start int
+	end   int
+}
+
+// counter is a known position (in bytes) and the count (e.g. of runes) up to that position - positions are counted
+// from the last position asked for (rather than from the start of the string), as successive positions asked for
+// are usually close together
+type counter struct {
+	at    int
+	count int
+}
+
+// move moves the counter to the byte position - returning the count at that position
+func (c *counter) move(str string, pos int, count func(string) int) int {
+	if pos >= c.at {
+		c.count += count(str[c.at:pos])
+	} else {
+		c.count -= count(str[pos:c.at])
+	}
+	c.at = pos
+	return c.count
 }
 
 func newSplitterContext(str string, splitter *splitter, options []Option) *splitterContext {
-	runes := []rune(str)
+	return newRangeSplitterContext(str, 0, len(str), 0, splitter, options)
+}
+
+// newRangeSplitterContext creates a splitter context that splits only the range (from/to byte positions) of the string -
+// positions remain relative to the original string (the `fromRune` arg being the rune position of `from`)
+func newRangeSplitterContext(str string, from int, to int, fromRune int, splitter *splitter, options []Option) *splitterContext {
 	cp := 1
-	for _, r := range runes {
-		if r == splitter.separator {
-			cp++
-		}
+	if splitter.separator != 0 {
+		cp += strings.Count(str[from:to], string(splitter.separator))
 	}
+	ctx := newLazySplitterContext(str, from, to, splitter, options)
+	ctx.runeCounter = counter{at: from, count: fromRune}
+	ctx.captured = make([]string, 0, cp)
+	return ctx
+}
+
+// newBytesSplitterContext creates a splitter context for UTF-8 encoded bytes - the bytes are split in place (i.e. without
+// being copied to a string)
+func newBytesSplitterContext(b []byte, splitter *splitter, options []Option) *splitterContext {
+	ctx := newSplitterContext(bytesString(b), splitter, options)
+	ctx.input = b
+	ctx.byteParts = make([][]byte, 0, cap(ctx.captured))
+	return ctx
+}
+
+// bytesString returns a string that shares the memory of the bytes (in the same way as strings.Builder.String)
+func bytesString(b []byte) string {
+	return *(*string)(unsafe.Pointer(&b))
+}
+
+// newLazySplitterContext creates a splitter context without pre-scanning the string to size the captured parts
+func newLazySplitterContext(str string, from int, to int, splitter *splitter, options []Option) *splitterContext {
 	return &splitterContext{
-		splitter: splitter,
-		options:  options,
-		runes:    runes,
-		lastAt:   0,
-		len:      len(runes),
-		current:  nil,
-		stack:    make([]*subPart, 0),
-		delims:   make([]SubPart, 0),
-		captured: make([]string, 0, cp),
+		splitter:  splitter,
+		options:   options,
+		str:       str,
+		start:     from,
+		pos:       from,
+		lastAt:    from,
+		len:       to,
+		current:   nil,
+		stack:     make([]*subPart, 0),
+		maxSplits: -1,
+		runeLen:   -1,
+		delims:    make([]SubPart, 0),
 	}
 }
 
 func (ctx *splitterContext) split() ([]string, error) {
-	ctx.pos = 0
-	for ; ctx.pos < ctx.len; ctx.pos++ {
-		ctx.rune = ctx.runes[ctx.pos]
-		if ctx.rune == ctx.splitter.separator {
-			if !ctx.inAny() {
-				if err := ctx.purge(ctx.pos, false); err != nil {
-					return nil, err
-				}
+	to := ctx.len
+	if ctx.partial {
+		// what is found near the end can only be determined once more input is read...
+		to -= ctx.lookahead
+	}
+	for ; ctx.pos < to; ctx.pos += ctx.size {
+		ctx.rune, ctx.size = utf8.DecodeRuneInString(ctx.str[ctx.pos:ctx.len])
+		ctx.endLineComment()
+		if ctx.splitDepth > 0 && ctx.pos > ctx.start && ctx.depth() < ctx.splitDepth {
+			return nil, newSplittingError(NotEnclosed, ctx.runePos(ctx.pos), ctx.rune, nil)
+		}
+		if n := ctx.separatorAt(); n > 0 {
+			if err := ctx.purge(ctx.pos, n, false); err != nil {
+				return nil, err
+			} else if ctx.stopped {
+				return nil, nil
+			}
+			ctx.size = n
+		} else if isEnd, n, inQuote := ctx.isQuoteEnd(); n > 0 {
+			if isEnd {
+				ctx.pop(ctx.pos, n)
 			}
-		} else if isEnd, inQuote := ctx.isQuoteEnd(); isEnd {
-			ctx.pop(ctx.pos)
+			ctx.size = n
+		} else if n < 0 {
+			// whether the quote ends here can only be determined once more input is read...
+			break
 		} else {
+			isClose, n, skipClose := false, 0, false
 			if !inQuote {
-				if isClose, skipClose := ctx.isClose(); isClose && !skipClose {
-					ctx.pop(ctx.pos)
-				} else if enc, isOpen := ctx.isOpener(); isOpen {
-					ctx.push(enc, ctx.pos)
-				} else if cEnc, ok := ctx.splitter.closers[ctx.rune]; ok && !skipClose {
-					return nil, newSplittingError(Unopened, ctx.pos, ctx.rune, &cEnc)
+				isClose, n, skipClose = ctx.isClose()
+			}
+			if isClose && !skipClose {
+				ctx.pop(ctx.pos, n)
+				ctx.size = n
+			} else if enc, on := ctx.isOpener(); enc != nil {
+				if err := ctx.checkNesting(enc); err != nil {
+					return nil, err
+				} else if enc.IsComment && ctx.splitter.commentPolicy == CommentsError {
+					return nil, newSplittingError(CommentFound, ctx.runePos(ctx.pos), ctx.rune, enc.copy())
 				}
+				ctx.push(enc, ctx.pos, on)
+				ctx.size = on
+			} else if on < 0 {
+				// whether an enclosure starts here can only be determined once more input is read...
+				break
+			} else if on > 0 {
+				// the start of a heredoc (whose body starts at the end of the line)...
+				ctx.size = on
+			} else if n > 0 && !skipClose {
+				cEnc, _ := ctx.closerAt()
+				return nil, newSplittingError(Unopened, ctx.runePos(ctx.pos), ctx.rune, cEnc.copy())
 			}
 		}
 	}
+	if ctx.partial {
+		return ctx.captured, nil
+	}
+	ctx.endLineComment()
 	if ctx.inAny() {
-		return nil, newSplittingError(Unclosed, ctx.current.openPos, ctx.current.enc.Start, &ctx.current.enc)
+		return nil, ctx.unclosedError()
+	} else if len(ctx.hereDocs) > 0 {
+		return nil, ctx.hereDocs[0].unclosedError()
 	}
-	if err := ctx.purge(ctx.len, true); err != nil {
+	if err := ctx.purge(ctx.len-ctx.trail, 0, true); err != nil {
 		return nil, err
 	}
 	return ctx.captured, nil
 }
 
-func (ctx *splitterContext) isQuoteEnd() (isEnd bool, inQuote bool) {
-	if ctx.current != nil && ctx.current.enc.IsQuote {
+// isQuoteEnd determines whether the current position is the end of the current quote (or other opaque enclosure) - also
+// returning the length of the end (or of an escaped end, which is to be skipped; negative if more input is needed to
+// determine the end) and whether currently within a quote
+func (ctx *splitterContext) isQuoteEnd() (isEnd bool, n int, inQuote bool) {
+	if ctx.current != nil && ctx.current.enc.isOpaque() {
 		inQuote = true
-		if ctx.current.enc.End == ctx.rune {
+		if ctx.current.enc.IsLineComment {
+			// line comments are ended by endLineComment...
+			return
+		} else if n = ctx.current.enc.endsAt(ctx.str, ctx.pos, ctx.len); n < 0 || (n > 0 && ctx.partial && ctx.pos+n >= ctx.len) {
+			// the end is only partly read (or more input might change it)...
+			if n = 0; ctx.partial {
+				n = -1
+			}
+		} else if n > 0 {
 			isEnd = true
 			if ctx.current.enc.isDoubleEscaping() {
-				if ctx.pos < ctx.len-1 && ctx.runes[ctx.pos+1] == ctx.rune {
+				if ctx.current.enc.endsAt(ctx.str, ctx.pos+n, ctx.len) > 0 {
 					isEnd = false
-					ctx.pos++
+					n += n
 				}
 			} else if ctx.current.enc.isEscapable() {
 				escaped := false
-				minPos := ctx.current.openPos
-				for i := ctx.pos - 1; i > minPos; i-- {
-					if ctx.runes[i] == ctx.current.enc.Escape {
-						escaped = !escaped
-					} else {
+				minPos := ctx.current.start + ctx.current.startLen
+				for i := ctx.pos; i > minPos; {
+					r, size := utf8.DecodeLastRuneInString(ctx.str[minPos:i])
+					if r != ctx.current.enc.Escape {
 						break
 					}
+					escaped = !escaped
+					i -= size
 				}
 				isEnd = !escaped
 			}
@@ -202,62 +677,552 @@ func (ctx *splitterContext) isQuoteEnd() (isEnd bool, inQuote bool) {
 	return
 }
 
-func (ctx *splitterContext) purge(i int, isLast bool) (err error) {
-	if i >= ctx.lastAt {
-		ctx.purgeFixed(i)
-		capture := string(ctx.runes[ctx.lastAt:i])
+func (ctx *splitterContext) purge(i int, sepLen int, isLast bool) (err error) {
+	if ctx.splitter.skipEmpties && i == ctx.lastAt {
+		if ctx.cutting && sepLen > 0 {
+			ctx.splits++
+		}
+		ctx.lastAt = i + sepLen
+	} else if i >= ctx.lastAt {
+		end := i
+		if ctx.sepMode == separatorsAfter {
+			end = i + sepLen
+		}
+		ctx.purgeFixed(end)
+		raw := ctx.str[ctx.lastAt:end]
+		capture := raw
+		subParts := ctx.delims
+		var segments []span
+		if len(ctx.comments) > 0 && ctx.splitter.commentPolicy == CommentsStrip {
+			capture, subParts, segments = ctx.stripComments(ctx.lastAt, end)
+		}
 		addIt := true
-		cLen := len(ctx.captured)
+		cLen := ctx.count
+		var sep Separator
+		if sepLen > 0 {
+			sep = &separatorPart{
+				start: i,
+				end:   i + sepLen,
+				ctx:   ctx,
+			}
+		}
+		pos, totalLen := 0, 0
+		if len(ctx.options) > 0 {
+			pos, totalLen = ctx.runePos(ctx.lastAt), ctx.totalLen()
+		}
+		ctx.value = nil
+		if len(ctx.options) > 0 {
+			if ctx.value = segments; segments == nil {
+				ctx.value = []span{{start: ctx.lastAt, end: end}}
+			}
+		}
 		for _, o := range ctx.options {
-			capture, addIt, err = o.Apply(capture, ctx.lastAt, ctx.len, cLen, ctx.skipped, isLast, ctx.delims...)
+			prev := capture
+			if co, ok := o.(contextOption); ok {
+				capture = co.applyIn(ctx, capture)
+			} else if so, ok := o.(SeparatorOption); ok {
+				capture, addIt, err = so.ApplySeparator(capture, pos, totalLen, cLen, ctx.skipped, isLast, sep, subParts...)
+			} else {
+				capture, addIt, err = o.Apply(capture, pos, totalLen, cLen, ctx.skipped, isLast, subParts...)
+			}
 			if !addIt || err != nil {
 				break
 			}
+			ctx.value = ctx.valueAfter(o, prev, capture, subParts)
+		}
+		if err != nil && ctx.probing {
+			// only finding which parts are added - errors are left to the actual split...
+			err, addIt = nil, true
 		}
-		err = asSplittingError(err, ctx.lastAt)
-		if addIt {
+		err = asSplittingError(err, pos)
+		if sepLen > 0 && (addIt || ctx.cutting) {
+			// only parts added count towards the maximum splits (except when cutting, which stops at the first separator)...
+			ctx.splits++
+		}
+		if addIt && ctx.yield != nil {
+			ctx.count++
+			ctx.stopped = !ctx.yield(ctx.newPart(ctx.lastAt, end, raw, capture, sep, subParts))
+		} else if addIt && ctx.byteParts != nil {
+			ctx.count++
+			ctx.byteParts = append(ctx.byteParts, ctx.partBytes(ctx.lastAt, end, raw, capture))
+		} else if addIt {
+			if ctx.sepMode == separatorsKept && ctx.count > 0 {
+				// the separator preceding the part (the last one, if any parts in between were not added)...
+				ctx.captured = append(ctx.captured, ctx.keptSep)
+			}
+			ctx.count++
 			ctx.captured = append(ctx.captured, capture)
+			if ctx.spans != nil {
+				ctx.spans = append(ctx.spans, span{start: ctx.lastAt, end: end})
+			}
+			if ctx.parts != nil {
+				ctx.parts = append(ctx.parts, ctx.newPart(ctx.lastAt, end, raw, capture, sep, subParts))
+			}
+			if ctx.separators != nil {
+				if sep != nil {
+					ctx.separators = append(ctx.separators, sep.String())
+				} else {
+					ctx.separators = append(ctx.separators, "")
+				}
+			}
 		} else {
 			ctx.skipped++
 		}
-		ctx.lastAt = i + 1
+		if ctx.sepMode == separatorsKept && sep != nil {
+			ctx.keptSep = sep.String()
+		}
+		ctx.lastAt = i + sepLen
 		ctx.delims = make([]SubPart, 0)
 	}
 	return
 }
 
+// valueAfter returns the segments (of the string being split) that make up the value returned by an option - given the
+// value that was passed to the option (made up of the current value segments)
+//
+// The segments are only known if the value is unchanged or is the result of trimming, stripping quotes or unescaping
+// separators - otherwise nil is returned
+func (ctx *splitterContext) valueAfter(o Option, prev string, value string, subParts []SubPart) []span {
+	if value == prev {
+		return ctx.value
+	}
+	var segments []span
+	switch opt := o.(type) {
+	case *trim:
+		if ctx.value != nil {
+			from := len(prev) - len(strings.TrimLeft(prev, opt.cutset))
+			segments = cutSegments(ctx.value, from, from+len(value))
+		}
+	case *stripQuotes:
+		segments = make([]span, 0, len(subParts))
+		for _, sub := range subParts {
+			sp, ok := sub.(*subPart)
+			if !ok {
+				return nil
+			} else if sp.IsQuote() {
+				segments = appendSegment(segments, span{start: sp.start + sp.startLen, end: sp.end - sp.enc.endLen()})
+			} else {
+				segments = appendSegment(segments, span{start: sp.start, end: sp.end})
+			}
+		}
+	case *unescapeSeparators:
+		if ctx.value != nil {
+			segments = ctx.withoutEscapes(ctx.value)
+		}
+	}
+	if segments != nil && ctx.isValue(segments, value) {
+		return segments
+	}
+	return nil
+}
+
+// appendSegment appends a segment of a value - joining it to the last segment if they are adjacent (an empty segment is
+// only kept as the position of an otherwise empty value)
+func appendSegment(segments []span, sg span) []span {
+	if l := len(segments); l > 0 && sg.start == sg.end {
+		return segments
+	} else if l > 0 && segments[l-1].start == segments[l-1].end {
+		segments[l-1] = sg
+	} else if l > 0 && segments[l-1].end == sg.start {
+		segments[l-1].end = sg.end
+	} else {
+		segments = append(segments, sg)
+	}
+	return segments
+}
+
+// cutSegments returns the segments of the part (from/to byte offsets) of the value made up of the segments
+func cutSegments(segments []span, from int, to int) []span {
+	result := make([]span, 0, len(segments))
+	offset := 0
+	for _, sg := range segments {
+		start, end := sg.start+from-offset, sg.start+to-offset
+		if start < sg.start {
+			start = sg.start
+		}
+		if end > sg.end {
+			end = sg.end
+		}
+		if start < end || (len(result) == 0 && start == end && from-offset <= sg.end-sg.start) {
+			result = appendSegment(result, span{start: start, end: end})
+		}
+		offset += sg.end - sg.start
+	}
+	return result
+}
+
+// withoutEscapes returns the segments excluding any separator escapes
+func (ctx *splitterContext) withoutEscapes(segments []span) []span {
+	escLen := utf8.RuneLen(ctx.splitter.sepEscape)
+	result := make([]span, 0, len(segments))
+	for _, sg := range segments {
+		start := sg.start
+		for i := sort.SearchInts(ctx.escapes, sg.start); i < len(ctx.escapes) && ctx.escapes[i] < sg.end; i++ {
+			result = appendSegment(result, span{start: start, end: ctx.escapes[i]})
+			start = ctx.escapes[i] + escLen
+		}
+		if start <= sg.end {
+			result = appendSegment(result, span{start: start, end: sg.end})
+		}
+	}
+	return result
+}
+
+// isValue determines whether the segments make up the value
+func (ctx *splitterContext) isValue(segments []span, value string) bool {
+	for _, sg := range segments {
+		if l := sg.end - sg.start; l > len(value) || ctx.str[sg.start:sg.end] != value[:l] {
+			return false
+		} else {
+			value = value[l:]
+		}
+	}
+	return value == ""
+}
+
+// segmentsString returns the text made up of the segments
+func (ctx *splitterContext) segmentsString(segments []span) string {
+	if len(segments) == 1 {
+		return ctx.str[segments[0].start:segments[0].end]
+	}
+	var sb strings.Builder
+	for _, sg := range segments {
+		sb.WriteString(ctx.str[sg.start:sg.end])
+	}
+	return sb.String()
+}
+
+// unescaped returns the text (from/to) with any separator escapes removed
+func (ctx *splitterContext) unescaped(from int, to int) string {
+	return ctx.segmentsString(ctx.withoutEscapes([]span{{start: from, end: to}}))
+}
+
+// unescapedText returns the text with the separator escapes of the current part removed - where the text is not made up
+// of the string being split (e.g. as changed by an option), each escaped rune (with its escape) is found in order
+func (ctx *splitterContext) unescapedText(s string) string {
+	escLen := utf8.RuneLen(ctx.splitter.sepEscape)
+	var sb strings.Builder
+	last := 0
+	for i := sort.SearchInts(ctx.escapes, ctx.lastAt); i < len(ctx.escapes); i++ {
+		e := ctx.escapes[i]
+		_, size := utf8.DecodeRuneInString(ctx.str[e+escLen:])
+		if at := strings.Index(s[last:], ctx.str[e:e+escLen+size]); at != -1 {
+			sb.WriteString(s[last : last+at])
+			last += at + escLen
+		}
+	}
+	if last == 0 {
+		return s
+	}
+	sb.WriteString(s[last:])
+	return sb.String()
+}
+
+// runePos returns the rune position (in the original string) of the byte position
+func (ctx *splitterContext) runePos(pos int) int {
+	return ctx.runeCounter.move(ctx.str, pos, utf8.RuneCountInString)
+}
+
+// utf16Pos returns the UTF-16 code unit position (in the original string) of the byte position
+func (ctx *splitterContext) utf16Pos(pos int) int {
+	return ctx.utf16Counter.move(ctx.str, pos, utf16Len)
+}
+
+// totalLen returns the position (in runes) of the end of the string being split
+func (ctx *splitterContext) totalLen() int {
+	if ctx.runeLen == -1 {
+		ctx.runeLen = ctx.runeCounter.count + utf8.RuneCountInString(ctx.str[ctx.runeCounter.at:ctx.len])
+	}
+	return ctx.runeLen
+}
+
+// utf16Len returns the length of the string in UTF-16 code units
+func utf16Len(str string) int {
+	n := 0
+	for _, r := range str {
+		if r >= 0x10000 && r <= unicode.MaxRune {
+			n += 2
+		} else {
+			n++
+		}
+	}
+	return n
+}
+
+// position returns the Position (in the original string) of the byte position
+func (ctx *splitterContext) position(pos int) Position {
+	return Position{
+		Rune:  ctx.runePos(pos),
+		Byte:  pos,
+		UTF16: ctx.utf16Pos(pos),
+	}
+}
+
+// endLineComment ends the current line comment if at the end of a line (or end of string)
+func (ctx *splitterContext) endLineComment() {
+	if ctx.current != nil && ctx.current.enc.IsLineComment && (ctx.pos >= ctx.len || ctx.rune == '\n' || ctx.rune == '\r') {
+		// the end of line is not part of the comment...
+		ctx.pop(ctx.pos, 0)
+	}
+}
+
+// stripComments returns the text (from/to) with any comments removed - along with the sub-parts excluding comments and
+// the segments that make up the text
+func (ctx *splitterContext) stripComments(from int, to int) (string, []SubPart, []span) {
+	var sb strings.Builder
+	segments := make([]span, 0, 2)
+	last := from
+	used := 0
+	for _, c := range ctx.comments {
+		if c.end > to {
+			break
+		} else if c.start >= last {
+			sb.WriteString(ctx.str[last:c.start])
+			segments = appendSegment(segments, span{start: last, end: c.start})
+			last = c.end
+		}
+		used++
+	}
+	sb.WriteString(ctx.str[last:to])
+	segments = appendSegment(segments, span{start: last, end: to})
+	// comments are recorded in order - so those used no longer need to be considered...
+	ctx.comments = ctx.comments[used:]
+	subParts := make([]SubPart, 0, len(ctx.delims))
+	for _, sp := range ctx.delims {
+		if !sp.IsComment() {
+			subParts = append(subParts, sp)
+		}
+	}
+	return sb.String(), subParts, segments
+}
+
+// partBytes returns the bytes for a captured part - a sub-slice of the input bytes if the captured part is unmodified
+func (ctx *splitterContext) partBytes(start int, end int, raw string, capture string) []byte {
+	if capture == raw {
+		return ctx.input[start:end:end]
+	}
+	return []byte(capture)
+}
+
+// separatorAt returns the length (in bytes) of the separator at the current position (zero if no separator or the
+// position is not at the split depth)
+func (ctx *splitterContext) separatorAt() int {
+	if ctx.depth() != ctx.splitDepth || (ctx.current != nil && ctx.current.enc.isOpaque()) {
+		return 0
+	}
+	n := ctx.splitter.sep.match(ctx)
+	if n > 0 && len(ctx.hereDocs) > 0 {
+		// a newline starts the body of a pending heredoc - so the separator cannot include it...
+		if i := strings.IndexByte(ctx.str[ctx.pos:ctx.pos+n], '\n'); i != -1 {
+			n = i
+		}
+	}
+	if n > 0 && ctx.splitter.sepEscape != 0 && ctx.isEscapedSeparator() {
+		return 0
+	} else if n > 0 && (ctx.splits == ctx.maxSplits || (ctx.pos >= ctx.ignoreFrom && ctx.pos < ctx.ignoreTo)) {
+		return 0
+	} else if n > 0 && ctx.partial && ctx.pos+n >= ctx.len {
+		// more input might extend (or change) the separator...
+		return 0
+	}
+	return n
+}
+
+// isEscapedSeparator determines whether the separator at the current position is escaped (and records the escaping escapes)
+func (ctx *splitterContext) isEscapedSeparator() bool {
+	esc := ctx.splitter.sepEscape
+	from := ctx.pos
+	for from > ctx.lastAt {
+		if r, size := utf8.DecodeLastRuneInString(ctx.str[ctx.lastAt:from]); r == esc {
+			from -= size
+		} else {
+			break
+		}
+	}
+	escLen := utf8.RuneLen(esc)
+	count := (ctx.pos - from) / escLen
+	for i := 0; i < count-1; i += 2 {
+		ctx.escapes = append(ctx.escapes, from+i*escLen)
+	}
+	escaped := count%2 == 1
+	if escaped {
+		ctx.escapes = append(ctx.escapes, ctx.pos-escLen)
+	}
+	return escaped
+}
+
+// regexpMatch returns the start and end positions (and, if requested, the submatch positions) of the next match of the
+// regexp that does not start before the current position (if there are no further matches, the start and end are the end
+// of the string)
+//
+// As with regexp.Regexp.Split, the leftmost non-overlapping matches are found by searching the whole string (so that
+// empty-width assertions, such as `^` or `\b`, see the text preceding each match) - matches are only searched for again
+// from the current position when a match that was passed over (e.g. one starting within an enclosure) extends beyond it
+func (ctx *splitterContext) regexpMatch(rx *regexp.Regexp, submatches bool) []int {
+	if ctx.rxMatches == nil {
+		ctx.findRegexp(rx, submatches, ctx.lastAt, 0)
+	}
+	for {
+		if len(ctx.rxMatches) == 0 {
+			// more matches are needed (searching from the same position)...
+			ctx.findRegexp(rx, submatches, ctx.rxFrom, ctx.rxFound)
+		} else if m := ctx.rxMatches[0]; m[0] >= ctx.pos {
+			return m
+		} else if m[1] > ctx.pos && ctx.splits != ctx.maxSplits && (ctx.pos < ctx.ignoreFrom || ctx.pos >= ctx.ignoreTo) {
+			// the match passed over overlaps the current position - so later matches could differ...
+			ctx.findRegexp(rx, submatches, ctx.pos, 0)
+		} else {
+			ctx.rxMatches = ctx.rxMatches[1:]
+		}
+	}
+}
+
+// findRegexp finds matches of the regexp in the string from the byte position - skipping the number of matches already
+// found from that position (only the first few matches are found initially, in case splitting is stopped early)
+func (ctx *splitterContext) findRegexp(rx *regexp.Regexp, submatches bool, from int, skip int) {
+	n := 16
+	if skip > 0 {
+		n = -1
+	}
+	var found [][]int
+	if submatches {
+		found = rx.FindAllStringSubmatchIndex(ctx.str[from:ctx.len], n)
+	} else {
+		found = rx.FindAllStringIndex(ctx.str[from:ctx.len], n)
+	}
+	ctx.rxFrom, ctx.rxFound = from, len(found)
+	if skip > len(found) {
+		skip = len(found)
+	}
+	ctx.rxMatches = found[skip:]
+	for _, loc := range ctx.rxMatches {
+		for i, at := range loc {
+			if at != -1 {
+				loc[i] = from + at
+			}
+		}
+	}
+	if n == -1 || len(found) < n {
+		// no more matches...
+		ctx.rxMatches = append(ctx.rxMatches, []int{ctx.len, ctx.len})
+	}
+}
+
+// prevRune returns the rune preceding the current position (or zero if at the start)
+func (ctx *splitterContext) prevRune() rune {
+	if ctx.pos > ctx.start {
+		r, _ := utf8.DecodeLastRuneInString(ctx.str[ctx.start:ctx.pos])
+		return r
+	}
+	return 0
+}
+
+// depth returns the current enclosure nesting depth
+func (ctx *splitterContext) depth() int {
+	if ctx.current == nil {
+		return 0
+	}
+	return len(ctx.stack) + 1
+}
+
 func (ctx *splitterContext) inAny() bool {
 	return ctx.current != nil
 }
 
-func (ctx *splitterContext) isClose() (is bool, skip bool) {
-	skip = false
-	is = ctx.current != nil && ctx.current.enc.End == ctx.rune
-	if ctx.pos > 0 {
-		if enc, ok := ctx.splitter.closers[ctx.rune]; ok {
-			skip = enc.isBracketEscapable() && ctx.runes[ctx.pos-1] == enc.Escape
+// isClose determines whether the current position is the close of the current enclosure - also returning the length
+// of any closer found at the position (of the current enclosure or otherwise) and whether that closer is escaped
+func (ctx *splitterContext) isClose() (is bool, n int, skip bool) {
+	if ctx.current != nil {
+		if n = ctx.current.enc.endsAt(ctx.str, ctx.pos, ctx.len); n > 0 {
+			return true, n, ctx.isEscapedBracket(ctx.current.enc)
 		}
 	}
-	return
+	if enc, n := ctx.closerAt(); n > 0 {
+		return false, n, ctx.isEscapedBracket(enc)
+	}
+	return false, 0, false
 }
 
-func (ctx *splitterContext) isOpener() (Enclosure, bool) {
-	enc, is := ctx.splitter.openers[ctx.rune]
-	skip := is && ctx.pos > 0 && enc.isBracketEscapable() && ctx.runes[ctx.pos-1] == enc.Escape
-	is = is && !skip
-	return enc, is
+// closerAt returns the enclosure whose end is found at the current position (longest end first) and the length of that end
+func (ctx *splitterContext) closerAt() (*Enclosure, int) {
+	closers := ctx.splitter.closers[ctx.rune]
+	for i := range closers {
+		if n := closers[i].endsAt(ctx.str, ctx.pos, ctx.len); n > 0 {
+			return &closers[i], n
+		}
+	}
+	return nil, 0
 }
 
-func (ctx *splitterContext) push(enc Enclosure, pos int) {
-	if ctx.current != nil {
-		ctx.stack = append(ctx.stack, ctx.current)
+// isOpener determines whether an (unescaped) enclosure start is found at the current position (longest start first) -
+// returning the enclosure (with any dynamic start/end resolved) and the length of its start - or nil if no start is found
+//
+// within an opaque enclosure, only the allowed children of that enclosure are considered
+//
+// the start of a heredoc is recorded as pending (returning a nil enclosure, but the length of the start) - the body of the
+// heredoc being the enclosure returned at the next newline
+func (ctx *splitterContext) isOpener() (*Enclosure, int) {
+	inOpaque := ctx.current != nil && ctx.current.enc.isOpaque()
+	if len(ctx.hereDocs) > 0 && ctx.rune == '\n' && !inOpaque {
+		enc := ctx.hereDocs[0].enc
+		ctx.hereDocs = ctx.hereDocs[1:]
+		return enc, 1
+	} else if inOpaque && len(ctx.current.enc.AllowedChildren) == 0 {
+		return nil, 0
+	}
+	openers := ctx.splitter.openers[ctx.rune]
+	for i := range openers {
+		if inOpaque && !ctx.current.enc.allows(&openers[i]) {
+			continue
+		} else if sEnc, n := openers[i].startAt(ctx.str, ctx.start, ctx.pos, ctx.len); ctx.partial && (n < 0 || (n > 0 && ctx.pos+n >= ctx.len)) {
+			// the start is only partly read (or more input might change it)...
+			return nil, -1
+		} else if n > 0 {
+			if ctx.isEscapedBracket(sEnc) {
+				return nil, 0
+			} else if sEnc.isHereDoc() {
+				ctx.hereDocs = append(ctx.hereDocs, pendingHereDoc{enc: sEnc, runePos: ctx.runePos(ctx.pos)})
+				return nil, n
+			}
+			return sEnc, n
+		}
+	}
+	return nil, 0
+}
+
+// checkNesting returns a NestingViolation error if the enclosure (starting at the current position) is not allowed
+// within the current enclosure or exceeds its maximum depth
+func (ctx *splitterContext) checkNesting(enc *Enclosure) SplittingError {
+	if (ctx.current != nil && !ctx.current.enc.allows(enc)) || (enc.MaxDepth > 0 && ctx.depth() >= enc.MaxDepth) {
+		return newSplittingError(NestingViolation, ctx.runePos(ctx.pos), ctx.rune, enc.copy())
+	}
+	return nil
+}
+
+// isEscapedBracket determines whether the bracket start/end at the current position is escaped
+func (ctx *splitterContext) isEscapedBracket(enc *Enclosure) bool {
+	return enc.isBracketEscapable() && ctx.pos > ctx.start && ctx.prevRune() == enc.Escape
+}
+
+func (ctx *splitterContext) push(enc *Enclosure, pos int, n int) {
+	parent := ctx.current
+	if parent != nil {
+		ctx.stack = append(ctx.stack, parent)
 	}
 	ctx.current = &subPart{
-		openPos: pos,
-		enc:     enc,
-		ctx:     ctx,
+		start:    pos,
+		startLen: n,
+		enc:      enc,
+		ctx:      ctx,
 	}
-	if len(ctx.stack) == 0 {
+	if !enc.isOpaque() || len(enc.AllowedChildren) > 0 {
+		ctx.current.children = make([]SubPart, 0)
+	}
+	if parent != nil {
+		parent.purgeFixed(pos)
+		parent.children = append(parent.children, ctx.current)
+	}
+	if ctx.depth() == ctx.splitDepth+1 {
 		ctx.purgeFixed(pos)
 		ctx.delims = append(ctx.delims, ctx.current)
 	}
@@ -265,22 +1230,28 @@ func (ctx *splitterContext) push(enc Enclosure, pos int) {
 
 func (ctx *splitterContext) purgeFixed(pos int) {
 	last := ctx.lastAt
-	if len(ctx.delims) > 0 {
-		last = ctx.delims[len(ctx.delims)-1].EndPos() + 1
+	if l := len(ctx.delims); l > 0 {
+		last = ctx.delims[l-1].(*subPart).end
 	}
 	if last < pos {
 		ctx.delims = append(ctx.delims, &subPart{
-			enc:      Enclosure{},
-			openPos:  last,
-			closePos: pos - 1,
-			ctx:      ctx,
-			fixed:    true,
+			enc:   fixedEnclosure,
+			start: last,
+			end:   pos,
+			ctx:   ctx,
+			fixed: true,
 		})
 	}
 }
 
-func (ctx *splitterContext) pop(pos int) {
-	ctx.current.closePos = pos
+func (ctx *splitterContext) pop(pos int, n int) {
+	ctx.current.end = pos + n
+	if ctx.current.enc.IsComment && ctx.depth() == ctx.splitDepth+1 {
+		ctx.comments = append(ctx.comments, span{start: ctx.current.start, end: pos + n})
+	}
+	if ctx.current.children != nil {
+		ctx.current.purgeFixed(pos)
+	}
 	if l := len(ctx.stack); l > 0 {
 		ctx.current = ctx.stack[l-1]
 		ctx.stack = ctx.stack[0 : l-1]
@@ -3,6 +3,7 @@ package splitter
 import (
 	"fmt"
 	"github.com/stretchr/testify/require"
+	"regexp"
 	"testing"
 )
 
@@ -47,6 +48,24 @@ func TestNewSplitter_Errors(t *testing.T) {
 	_, err = NewSplitter('/', enc, enc2)
 	require.Error(t, err)
 	require.Equal(t, "existing end encloser ('}' in Enclosure[2])", err.Error())
+
+	_, err = NewSplitter('/', DoubleQuotes, BlockComments)
+	require.Error(t, err)
+	require.Equal(t, "separator '/' starts encloser ('/*' in Enclosure[2])", err.Error())
+	_, err = NewSplitter('-', DoubleDashLineComments)
+	require.Error(t, err)
+	require.Equal(t, "separator '-' starts encloser ('--' in Enclosure[1])", err.Error())
+	_, err = NewSplitter('#', HashLineComments)
+	require.Error(t, err)
+	require.Equal(t, "separator '#' starts encloser ('#' in Enclosure[1])", err.Error())
+	_, err = NewSplitter('<', HereDocs)
+	require.Error(t, err)
+	_, err = NewSplitter('\'', EscapePrefixedSingleQuotes)
+	require.Error(t, err)
+	_, err = NewSplitter('e', EscapePrefixedSingleQuotes)
+	require.Error(t, err)
+	_, err = NewSplitter('*', BlockComments)
+	require.NoError(t, err)
 }
 
 func TestMustCreateSplitter_Panics(t *testing.T) {
@@ -131,6 +150,724 @@ func TestSplitter_Split(t *testing.T) {
 	}
 }
 
+func TestSplitter_SplitWithSeparators(t *testing.T) {
+	s, err := NewSplitter('/', DoubleQuotes)
+	require.NoError(t, err)
+
+	parts, seps, err := s.SplitWithSeparators(`/a/"b/c"`)
+	require.NoError(t, err)
+	require.Equal(t, []string{``, `a`, `"b/c"`}, parts)
+	require.Equal(t, []string{`/`, `/`, ``}, seps)
+
+	parts, seps, err = s.SplitWithSeparators(``)
+	require.NoError(t, err)
+	require.Equal(t, []string{``}, parts)
+	require.Equal(t, []string{``}, seps)
+
+	_, _, err = s.SplitWithSeparators(`a/"b`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `"`, 2), err.Error())
+}
+
+func TestSplitter_SetSeparatorEscape(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+	rs := s.(*splitter)
+	require.Equal(t, int32(0), rs.sepEscape)
+	require.Equal(t, s, s.SetSeparatorEscape('\\'))
+	require.Equal(t, '\\', rs.sepEscape)
+
+	testCases := []struct {
+		str    string
+		expect []string
+	}{
+		{
+			`a\,b,c`,
+			[]string{`a\,b`, `c`},
+		},
+		{
+			`a\\,b`,
+			[]string{`a\\`, `b`},
+		},
+		{
+			`a\\\,b`,
+			[]string{`a\\\,b`},
+		},
+		{
+			`\,`,
+			[]string{`\,`},
+		},
+		{
+			`a,\,(b\,c),"\"`,
+			[]string{`a`, `\,(b\,c)`, `"\"`},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			result, err := s.Split(tc.str)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, result)
+		})
+	}
+
+	s.SetSeparatorEscape(0)
+	result, err := s.Split(`a\,b`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a\`, `b`}, result)
+}
+
+func TestSplitter_SetSeparatorEscape_OtherSeparators(t *testing.T) {
+	s := MustCreateStringSplitter("::").SetSeparatorEscape('\\')
+	result, err := s.Split(`a\::b::c`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a\::b`, `c`}, result)
+
+	s = MustCreateWhitespaceSplitter().SetSeparatorEscape('\\')
+	result, err = s.Split(`a\ b c`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a\ b`, `c`}, result)
+
+	s = MustCreateMultiSplitter([]rune{',', ';'}).SetSeparatorEscape('\\')
+	result, err = s.Split(`a\;b;c\,d,e`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a\;b`, `c\,d`, `e`}, result)
+}
+
+func TestSplitter_SplitEnclosed(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis, SquareBrackets)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str    string
+		expect []string
+	}{
+		{
+			`[a, "b,c", (d,e)]`,
+			[]string{`a`, ` "b,c"`, ` (d,e)`},
+		},
+		{
+			`(x,y,z)`,
+			[]string{`x`, `y`, `z`},
+		},
+		{
+			`()`,
+			[]string{``},
+		},
+		{
+			`(,)`,
+			[]string{``, ``},
+		},
+		{
+			`[[a,b],[c,d]]`,
+			[]string{`[a,b]`, `[c,d]`},
+		},
+		{
+			`"a,b"`,
+			[]string{`a,b`},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			result, err := s.SplitEnclosed(tc.str)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, result)
+		})
+	}
+}
+
+func TestSplitter_SplitEnclosed_SubParts(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis, SquareBrackets)
+	require.NoError(t, err)
+
+	c := &infoCapture{}
+	parts, err := s.SplitEnclosed(`[a, "b,c"(d)]`, c, TrimSpaces)
+	//                             0123456789012
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `"b,c"(d)`}, parts)
+	require.Equal(t, 2, c.called)
+	require.Equal(t, []int{1, 3, 4, 9}, c.startPositions)
+	require.Equal(t, []int{1, 3, 8, 11}, c.endPositions)
+	require.Equal(t, []SubPartType{Fixed, Fixed, Quotes, Brackets}, c.types)
+
+	parts, err = MustCreateWhitespaceSplitter(Parenthesis).SplitEnclosed(`( a  b (c d) )`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `b`, `(c d)`}, parts)
+}
+
+func TestSplitter_SplitEnclosed_Errors(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis, SquareBrackets)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str       string
+		expectErr string
+		expectPos int
+	}{
+		{
+			``,
+			fmt.Sprintf(notEnclosedFmt, 0),
+			0,
+		},
+		{
+			`a,b`,
+			fmt.Sprintf(notEnclosedFmt, 0),
+			0,
+		},
+		{
+			`(a,b),c`,
+			fmt.Sprintf(notEnclosedFmt, 5),
+			5,
+		},
+		{
+			`(a)(b)`,
+			fmt.Sprintf(notEnclosedFmt, 3),
+			3,
+		},
+		{
+			`(a,b`,
+			fmt.Sprintf(unclosedFmt, "(", 0),
+			0,
+		},
+		{
+			`(a,[b)`,
+			fmt.Sprintf(unopenedFmt, ")", 5),
+			5,
+		},
+		{
+			`(a])`,
+			fmt.Sprintf(unopenedFmt, "]", 2),
+			2,
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			_, err := s.SplitEnclosed(tc.str)
+			require.Error(t, err)
+			require.Equal(t, tc.expectErr, err.Error())
+			require.Equal(t, tc.expectPos, err.(SplittingError).Position())
+		})
+	}
+	_, err = s.SplitEnclosed(`a`)
+	require.Equal(t, NotEnclosed, err.(SplittingError).Type())
+}
+
+func TestSplitter_SplitAfter(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str    string
+		expect []string
+	}{
+		{
+			`a,b,c`,
+			[]string{`a,`, `b,`, `c`},
+		},
+		{
+			`a,"b,c",(d,e),`,
+			[]string{`a,`, `"b,c",`, `(d,e),`, ``},
+		},
+		{
+			`,`,
+			[]string{`,`, ``},
+		},
+		{
+			``,
+			[]string{``},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			result, err := s.SplitAfter(tc.str)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, result)
+		})
+	}
+
+	c := &infoCapture{}
+	result, err := s.SplitAfter(` a , "b" ,`, c, TrimSpaces, IgnoreEmpties)
+	//                           0123456789
+	require.NoError(t, err)
+	require.Equal(t, []string{`a ,`, `"b" ,`}, result)
+	require.Equal(t, 3, c.called)
+	require.Equal(t, []int{0, 4, 5, 8}, c.startPositions)
+	require.Equal(t, []int{3, 4, 7, 9}, c.endPositions)
+
+	result, err = MustCreateStringSplitter("::").SplitAfter(`a::b`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a::`, `b`}, result)
+
+	_, err = s.SplitAfter(`a,(b`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, "(", 2), err.Error())
+}
+
+func TestSplitter_SplitKeepSeparators(t *testing.T) {
+	s, err := NewMultiSplitter([]rune{',', ';'}, DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str    string
+		expect []string
+	}{
+		{
+			`a,b;c`,
+			[]string{`a`, `,`, `b`, `;`, `c`},
+		},
+		{
+			`a,"b,c";(d,e),`,
+			[]string{`a`, `,`, `"b,c"`, `;`, `(d,e)`, `,`, ``},
+		},
+		{
+			`,`,
+			[]string{``, `,`, ``},
+		},
+		{
+			``,
+			[]string{``},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			result, err := s.SplitKeepSeparators(tc.str)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, result)
+		})
+	}
+
+	result, err := s.SplitKeepSeparators(`,a,,b,`, IgnoreEmpties)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `,`, `b`}, result)
+
+	result, err = s.SplitKeepSeparators(`a,,b`, IgnoreEmpties)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `,`, `b`}, result)
+
+	result, err = s.SplitKeepSeparators(`a;,b`, IgnoreEmpties)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `,`, `b`}, result)
+
+	result, err = s.SplitKeepSeparators(`,a,b`, IgnoreEmptyFirst)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `,`, `b`}, result)
+
+	result, err = s.SplitKeepSeparators(`,,`, IgnoreEmpties)
+	require.NoError(t, err)
+	require.Equal(t, []string{}, result)
+
+	result, err = MustCreateWhitespaceSplitter().SplitKeepSeparators(` a  b `)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `  `, `b`}, result)
+
+	_, err = s.SplitKeepSeparators(`a,,b`, NoEmpties)
+	require.Error(t, err)
+
+	result, err = MustCreateRegexpSplitter(regexp.MustCompile(`\s*,\s*`)).SplitKeepSeparators(`a , b`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, ` , `, `b`}, result)
+}
+
+func TestSplitter_SplitN(t *testing.T) {
+	s, err := NewSplitter('=', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str    string
+		n      int
+		expect []string
+	}{
+		{
+			`key=value=with=equals`,
+			2,
+			[]string{`key`, `value=with=equals`},
+		},
+		{
+			`key=value=with=equals`,
+			3,
+			[]string{`key`, `value`, `with=equals`},
+		},
+		{
+			`key=value=with=equals`,
+			1,
+			[]string{`key=value=with=equals`},
+		},
+		{
+			`key=value=with=equals`,
+			10,
+			[]string{`key`, `value`, `with`, `equals`},
+		},
+		{
+			`key=value=with=equals`,
+			-1,
+			[]string{`key`, `value`, `with`, `equals`},
+		},
+		{
+			`key=value=with=equals`,
+			0,
+			nil,
+		},
+		{
+			`"k=ey"=(v=al)=ue`,
+			2,
+			[]string{`"k=ey"`, `(v=al)=ue`},
+		},
+		{
+			`=`,
+			2,
+			[]string{``, ``},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			result, err := s.SplitN(tc.str, tc.n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, result)
+		})
+	}
+
+	result, err := MustCreateWhitespaceSplitter(DoubleQuotes).SplitN(` cmd  arg1 rest of "line, here" `, 3)
+	require.NoError(t, err)
+	require.Equal(t, []string{`cmd`, `arg1`, `rest of "line, here" `}, result)
+
+	// parts not added do not count towards n...
+	cs := MustCreateSplitter(',')
+	result, err = cs.SplitN(`,,a,b`, 2, IgnoreEmpties)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `b`}, result)
+	result, err = cs.SplitN(`a,,b,c`, 2, IgnoreEmpties)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `,b,c`}, result)
+
+	result, err = s.SplitN(` a = b = c `, 2, TrimSpaces)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `b = c`}, result)
+
+	_, err = s.SplitN(`a=b=(c`, 2)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, "(", 4), err.Error())
+}
+
+func TestSplitter_RSplitN(t *testing.T) {
+	s, err := NewSplitter(':', DoubleQuotes, SquareBrackets)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str    string
+		n      int
+		expect []string
+	}{
+		{
+			`host:name:8080`,
+			2,
+			[]string{`host:name`, `8080`},
+		},
+		{
+			`[::1]:8080`,
+			2,
+			[]string{`[::1]`, `8080`},
+		},
+		{
+			`a:b:c:d`,
+			3,
+			[]string{`a:b`, `c`, `d`},
+		},
+		{
+			`a:b:c:d`,
+			1,
+			[]string{`a:b:c:d`},
+		},
+		{
+			`a:b:c:d`,
+			10,
+			[]string{`a`, `b`, `c`, `d`},
+		},
+		{
+			`a:b:c:d`,
+			-1,
+			[]string{`a`, `b`, `c`, `d`},
+		},
+		{
+			`a:b:c:d`,
+			0,
+			nil,
+		},
+		{
+			`a:"b:c"`,
+			2,
+			[]string{`a`, `"b:c"`},
+		},
+		{
+			`:`,
+			2,
+			[]string{``, ``},
+		},
+		{
+			`::`,
+			2,
+			[]string{`:`, ``},
+		},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			result, err := s.RSplitN(tc.str, tc.n)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, result)
+		})
+	}
+
+	result, err := MustCreateWhitespaceSplitter(DoubleQuotes).RSplitN(` a b  "c d"  e `, 2)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a b  "c d"`, `e`}, result)
+
+	result, err = s.RSplitN(` a : b : c `, 2, TrimSpaces)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a : b`, `c`}, result)
+
+	es := MustCreateSplitter('=')
+	result, err = es.RSplitN(`a=b==`, 2, IgnoreEmpties)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `b`}, result)
+	result, err = es.RSplitN(`a=b=c==`, 2, IgnoreEmpties)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a=b`, `c`}, result)
+	result, err = es.RSplitN(`a==b=c`, 2, NoEmpties)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a==b`, `c`}, result)
+	_, err = es.RSplitN(`a=b=c=`, 2, NoEmpties)
+	require.Error(t, err)
+
+	_, err = s.RSplitN(`a:b:[c`, 2)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, "[", 4), err.Error())
+}
+
+func TestSplitter_Each(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	values := make([]string, 0)
+	err = s.Each(` a,"b,c" ,(d,e)`, func(part Part) bool {
+		values = append(values, part.Value)
+		return true
+	}, TrimSpaces)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `"b,c"`, `(d,e)`}, values)
+
+	// early termination...
+	values = make([]string, 0)
+	err = s.Each(`a,b,c,(d`, func(part Part) bool {
+		values = append(values, part.Value)
+		return part.Value != `b`
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `b`}, values)
+
+	// errors surface when encountered...
+	values = make([]string, 0)
+	err = s.Each(`a,b,c),d`, func(part Part) bool {
+		values = append(values, part.Value)
+		return true
+	})
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unopenedFmt, ")", 5), err.Error())
+	require.Equal(t, []string{`a`, `b`}, values)
+
+	values = make([]string, 0)
+	err = s.Each(`a,b,(c`, func(part Part) bool {
+		values = append(values, part.Value)
+		return true
+	})
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, "(", 4), err.Error())
+	require.Equal(t, []string{`a`, `b`}, values)
+
+	// options are counted...
+	values = make([]string, 0)
+	err = s.Each(`,a,`, func(part Part) bool {
+		values = append(values, part.Value)
+		return true
+	}, IgnoreEmptyFirst, NotEmptyLast)
+	require.Error(t, err)
+	require.Equal(t, []string{`a`}, values)
+
+	// positions are in runes, bytes and UTF-16 code units...
+	spans := make([]Span, 0)
+	err = s.Each(`日,"😀,b",(c`, func(part Part) bool {
+		spans = append(spans, part.Span)
+		return true
+	})
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, "(", 8), err.Error())
+	require.Equal(t, []Span{
+		{Start: Position{Rune: 0, Byte: 0, UTF16: 0}, End: Position{Rune: 1, Byte: 3, UTF16: 1}},
+		{Start: Position{Rune: 2, Byte: 4, UTF16: 2}, End: Position{Rune: 7, Byte: 12, UTF16: 8}},
+	}, spans)
+}
+
+func TestSplitter_SplitBytes(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotesBackSlashEscaped, Parenthesis)
+	require.NoError(t, err)
+	s.SetSeparatorEscape('\\')
+	rs, err := NewRegexpSplitter(regexp.MustCompile(`\s*;\s*`), DoubleQuotes)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		splitter Splitter
+		str      string
+		options  []Option
+	}{
+		{s, ``, nil},
+		{s, `a,b,c`, nil},
+		{s, `a,"b,\"c",(d,e),`, nil},
+		{s, `a\,b,c`, nil},
+		{s, `a\,b,c`, []Option{UnescapeSeparators}},
+		{s, ` 日本 , 語 `, []Option{TrimSpaces}},
+		{s, ",a,,b,", []Option{IgnoreEmpties}},
+		{rs, `a ; "b;c";d;  e`, nil},
+		{rs, `日本;語`, nil},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			expect, err := tc.splitter.Split(tc.str, tc.options...)
+			require.NoError(t, err)
+			parts, err := tc.splitter.SplitBytes([]byte(tc.str), tc.options...)
+			require.NoError(t, err)
+			actual := make([]string, len(parts))
+			for pi, pt := range parts {
+				actual[pi] = string(pt)
+			}
+			require.Equal(t, expect, actual)
+		})
+	}
+
+	// unmodified parts are sub-slices of the input...
+	b := []byte(`aa, bb ,cc`)
+	parts, err := s.SplitBytes(b, TrimSpaces)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(parts))
+	require.True(t, &b[0] == &parts[0][0])
+	require.Equal(t, 2, cap(parts[0]))
+	require.False(t, &b[4] == &parts[1][0])
+	require.True(t, &b[8] == &parts[2][0])
+
+	// invalid UTF-8 is retained in unmodified parts...
+	parts, err = s.SplitBytes([]byte("a,\xff,b"))
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("a"), []byte("\xff"), []byte("b")}, parts)
+
+	_, err = s.SplitBytes([]byte(`a,(b`))
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, "(", 2), err.Error())
+	_, err = s.SplitBytes([]byte(`a,b)`))
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unopenedFmt, ")", 3), err.Error())
+}
+
+func TestSplitter_Cut(t *testing.T) {
+	s, err := NewSplitter('=', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str          string
+		options      []Option
+		expectBefore string
+		expectAfter  string
+		expectFound  bool
+	}{
+		{``, nil, ``, ``, false},
+		{`a`, nil, `a`, ``, false},
+		{`=`, nil, ``, ``, true},
+		{`a=b`, nil, `a`, `b`, true},
+		{`a=b=c`, nil, `a`, `b=c`, true},
+		{`name="a=b"`, nil, `name`, `"a=b"`, true},
+		{`"a=b"=c`, nil, `"a=b"`, `c`, true},
+		{`(a=b)`, nil, `(a=b)`, ``, false},
+		{` name = "a=b" `, []Option{TrimSpaces}, `name`, `"a=b"`, true},
+		{` name = "a=b" `, []Option{StripQuotes, TrimSpaces}, `name`, `a=b`, true},
+		{` "a=b" `, []Option{StripQuotes, TrimSpaces}, `a=b`, ``, false},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			before, after, found, err := s.Cut(tc.str, tc.options...)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectBefore, before)
+			require.Equal(t, tc.expectAfter, after)
+			require.Equal(t, tc.expectFound, found)
+		})
+	}
+
+	hs, err := NewStringSplitter(": ", DoubleQuotes)
+	require.NoError(t, err)
+	hs.AddDefaultOptions(StripQuotes)
+	before, after, found, err := hs.Cut(`header: "x: y"`)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, `header`, before)
+	require.Equal(t, `x: y`, after)
+
+	before, after, found, err = s.Cut(`=a=b`, IgnoreEmpties)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, ``, before)
+	require.Equal(t, `a=b`, after)
+	before, after, found, err = s.Cut(`=x`, IgnoreEmpties)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, ``, before)
+	require.Equal(t, `x`, after)
+	before, after, found, err = s.Cut(`x=`, IgnoreEmpties)
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, `x`, before)
+	require.Equal(t, ``, after)
+
+	_, _, _, err = s.Cut(`a=(b`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, "(", 2), err.Error())
+	_, _, _, err = s.Cut(`a=b)`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unopenedFmt, ")", 3), err.Error())
+	_, _, _, err = s.Cut(`=b`, NoEmpties)
+	require.Error(t, err)
+}
+
+func TestSplitter_CutLast(t *testing.T) {
+	s, err := NewSplitter('.', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str          string
+		options      []Option
+		expectBefore string
+		expectAfter  string
+		expectFound  bool
+	}{
+		{``, nil, ``, ``, false},
+		{`a`, nil, `a`, ``, false},
+		{`.`, nil, ``, ``, true},
+		{`a.b`, nil, `a`, `b`, true},
+		{`a.b.c`, nil, `a.b`, `c`, true},
+		{`a.b."c.d"`, nil, `a.b`, `"c.d"`, true},
+		{`a.b.(c.d)`, nil, `a.b`, `(c.d)`, true},
+		{`a.b.`, nil, `a.b`, ``, true},
+		{`(a.b)`, nil, `(a.b)`, ``, false},
+		{`a . b . c `, []Option{TrimSpaces}, `a . b`, `c`, true},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			before, after, found, err := s.CutLast(tc.str, tc.options...)
+			require.NoError(t, err)
+			require.Equal(t, tc.expectBefore, before)
+			require.Equal(t, tc.expectAfter, after)
+			require.Equal(t, tc.expectFound, found)
+		})
+	}
+
+	_, _, _, err = s.CutLast(`a.(b`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, "(", 2), err.Error())
+}
+
 func TestSplitter_Split_DoubleEscapes(t *testing.T) {
 	s, err := NewSplitter(',', DoubleQuotesDoubleEscaped)
 	require.NoError(t, err)
@@ -326,6 +1063,62 @@ func TestSplitter_Split_Contiguous(t *testing.T) {
 	require.Equal(t, ` `, c.unescaped[5])
 }
 
+func TestSplitter_Split_SubPartChildren(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	c := &subPartsCapture{}
+	parts, err := s.Split(`fn("a", ("b", c)),"d"`, c)
+	//                     0123456789012345678901
+	require.NoError(t, err)
+	require.Equal(t, 2, len(parts))
+	require.Equal(t, 2, len(c.subParts))
+
+	first := c.subParts[0]
+	require.Equal(t, 2, len(first))
+	require.True(t, first[0].IsFixed())
+	require.Nil(t, first[0].Children())
+	fn := first[1]
+	require.True(t, fn.IsBrackets())
+	require.Equal(t, `("a", ("b", c))`, fn.String())
+	children := fn.Children()
+	require.Equal(t, 3, len(children))
+	require.True(t, children[0].IsQuote())
+	require.Equal(t, `"a"`, children[0].String())
+	require.Nil(t, children[0].Children())
+	require.True(t, children[1].IsFixed())
+	require.Equal(t, `, `, children[1].String())
+	require.True(t, children[2].IsBrackets())
+	require.Equal(t, 8, children[2].StartPos())
+	require.Equal(t, 15, children[2].EndPos())
+	grandChildren := children[2].Children()
+	require.Equal(t, 2, len(grandChildren))
+	require.True(t, grandChildren[0].IsQuote())
+	require.Equal(t, `"b"`, grandChildren[0].String())
+	require.True(t, grandChildren[1].IsFixed())
+	require.Equal(t, `, c`, grandChildren[1].String())
+
+	second := c.subParts[1]
+	require.Equal(t, 1, len(second))
+	require.True(t, second[0].IsQuote())
+	require.Nil(t, second[0].Children())
+
+	c = &subPartsCapture{}
+	_, err = s.Split(`()`, c)
+	require.NoError(t, err)
+	require.NotNil(t, c.subParts[0][0].Children())
+	require.Equal(t, 0, len(c.subParts[0][0].Children()))
+}
+
+type subPartsCapture struct {
+	subParts [][]SubPart
+}
+
+func (o *subPartsCapture) Apply(s string, pos int, totalLen int, captured int, skipped int, isLast bool, subParts ...SubPart) (string, bool, error) {
+	o.subParts = append(o.subParts, subParts)
+	return s, true, nil
+}
+
 type infoCapture struct {
 	called         int
 	subs           int
@@ -550,3 +1343,375 @@ func TestBracketsEscaping(t *testing.T) {
 		})
 	}
 }
+
+func TestSplitter_Split_MultiRuneEnclosures(t *testing.T) {
+	blockComments := &Enclosure{StartSeq: "/*", EndSeq: "*/", IsQuote: true}
+	htmlComments := &Enclosure{StartSeq: "<!--", EndSeq: "-->", IsQuote: true}
+	s, err := NewSplitter(',', DoubleQuotes, TripleDoubleQuotes, DoubleCurlyBrackets, CurlyBrackets, blockComments, htmlComments, LtGtAngleBrackets)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str    string
+		expect []string
+	}{
+		{`a,/* b,c */,d`, []string{`a`, `/* b,c */`, `d`}},
+		{`a,/* b,(c */,d`, []string{`a`, `/* b,(c */`, `d`}},
+		{`a,{{b,{c,d}}},e`, []string{`a`, `{{b,{c,d}}}`, `e`}},
+		{`a,{b,{{c,d}}},e`, []string{`a`, `{b,{{c,d}}}`, `e`}},
+		{`a,"""b,"c",d""",e`, []string{`a`, `"""b,"c",d"""`, `e`}},
+		{`"",a`, []string{`""`, `a`}},
+		{`"a,b",c`, []string{`"a,b"`, `c`}},
+		{`x,<!-- a,<b -->,<c,d>`, []string{`x`, `<!-- a,<b -->`, `<c,d>`}},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			parts, err := s.Split(tc.str)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, parts)
+		})
+	}
+
+	parts, err := s.Split(`"""a,"b"""`, StripQuotes)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a,"b`}, parts)
+
+	_, err = s.Split(`a,"""b""`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `"`, 2), err.Error())
+	_, err = s.Split(`a,b-->`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unopenedFmt, `-`, 3), err.Error())
+	_, err = s.Split(`a,b}}`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unopenedFmt, `}`, 3), err.Error())
+	_, err = s.Split(`a,{{b}`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unopenedFmt, `}`, 5), err.Error())
+	_, err = s.Split(`a,{{b`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `{`, 2), err.Error())
+
+	parts, err = s.SplitEnclosed(`{{a,{b,c},d}}`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `{b,c}`, `d`}, parts)
+	parts, err = s.SplitEnclosed(`{a,{{b,c}},d}`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `{{b,c}}`, `d`}, parts)
+}
+
+func TestSplitter_Split_MultiRuneEnclosures_Escaping(t *testing.T) {
+	s, err := NewSplitter(',', TripleDoubleQuotesBackSlashEscaped)
+	require.NoError(t, err)
+	parts, err := s.Split(`"""a\""",b""",c`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`"""a\""",b"""`, `c`}, parts)
+	parts, err = s.Split(`"""a\""",b""",c`, UnescapeQuotes)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a""",b`, `c`}, parts)
+	parts, err = s.Split(`"""a\\""",b`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`"""a\\"""`, `b`}, parts)
+
+	s, err = NewSplitter(',', MustMakeEscapable(TripleSingleQuotes, '\''))
+	require.NoError(t, err)
+	parts, err = s.Split(`'''a'''''',b''',c`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`'''a'''''',b'''`, `c`}, parts)
+	parts, err = s.Split(`'''a'''''',b''',c`, UnescapeQuotes)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a''',b`, `c`}, parts)
+
+	s, err = NewSplitter(',', MustMakeEscapable(DoubleCurlyBrackets, '\\'))
+	require.NoError(t, err)
+	parts, err = s.Split(`{{a,\}}b}},c`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`{{a,\}}b}}`, `c`}, parts)
+	parts, err = s.Split(`\{{a,b`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`\{{a`, `b`}, parts)
+}
+
+func TestSplitter_Split_MultiRuneEnclosures_SubParts(t *testing.T) {
+	s, err := NewSplitter(',', TripleDoubleQuotes, DoubleCurlyBrackets)
+	require.NoError(t, err)
+	spc := &subPartsCapture{}
+	_, err = s.Split(`a"""b"""{{c"""d"""}}`, spc)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(spc.subParts))
+	subParts := spc.subParts[0]
+	require.Equal(t, 3, len(subParts))
+	require.True(t, subParts[1].IsQuote())
+	require.Equal(t, 1, subParts[1].StartPos())
+	require.Equal(t, 7, subParts[1].EndPos())
+	require.Equal(t, '"', subParts[1].StartRune())
+	require.Equal(t, `b`, subParts[1].UnEscaped())
+	require.True(t, subParts[2].IsBrackets())
+	require.Equal(t, 8, subParts[2].StartPos())
+	require.Equal(t, 19, subParts[2].EndPos())
+	children := subParts[2].Children()
+	require.Equal(t, 2, len(children))
+	require.Equal(t, `c`, children[0].String())
+	require.Equal(t, `"""d"""`, children[1].String())
+}
+
+func TestNewSplitter_MultiRuneEnclosureConflicts(t *testing.T) {
+	_, err := NewSplitter(',', DoubleQuotes, TripleDoubleQuotes, CurlyBrackets, DoubleCurlyBrackets)
+	require.NoError(t, err)
+
+	_, err = NewSplitter(',', TripleDoubleQuotes, &Enclosure{StartSeq: `"""`, EndSeq: `'''`, IsQuote: true})
+	require.Error(t, err)
+	require.Equal(t, `existing start encloser ('"""' in Enclosure[2])`, err.Error())
+	_, err = NewSplitter(',', DoubleCurlyBrackets, &Enclosure{StartSeq: `<<`, EndSeq: `}}`})
+	require.Error(t, err)
+	require.Equal(t, `existing end encloser ('}}' in Enclosure[2])`, err.Error())
+	_, err = NewSplitter(',', DoubleCurlyBrackets, &Enclosure{Start: '<', EndSeq: `}}`})
+	require.Error(t, err)
+	require.Equal(t, `existing end encloser ('}}' in Enclosure[2])`, err.Error())
+}
+
+func TestSplitter_Split_Comments(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis, HashLineComments, BlockComments)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str     string
+		options []Option
+		expect  []string
+	}{
+		{`a, b # trailing, comment`, nil, []string{`a`, ` b `}},
+		{`a, b # trailing, comment`, []Option{TrimSpaces}, []string{`a`, `b`}},
+		{"a, b # comment, 1\nc, d", nil, []string{`a`, " b \nc", ` d`}},
+		{`x, /* a,b */ y`, nil, []string{`x`, `  y`}},
+		{`x, /* a,b */ y`, []Option{TrimSpaces}, []string{`x`, `y`}},
+		{`x, "/* a,b */" y`, nil, []string{`x`, ` "/* a,b */" y`}},
+		{`x, "a" /* "b,c" */`, []Option{StripQuotes, TrimSpaces}, []string{`x`, `a`}},
+		{`x, (a, /* ) */ b)`, nil, []string{`x`, ` (a, /* ) */ b)`}},
+		{`x, /* y */ (a /* z */ b)`, nil, []string{`x`, `  (a /* z */ b)`}},
+		{`#`, nil, []string{``}},
+		{`/**/`, nil, []string{``}},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			parts, err := s.Split(tc.str, tc.options...)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, parts)
+		})
+	}
+
+	_, err = s.Split(`a, /* b`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `/`, 3), err.Error())
+	_, err = s.Split(`a, b */`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unopenedFmt, `*`, 5), err.Error())
+	_, err = s.Split("(a, # b)\n")
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `(`, 0), err.Error())
+}
+
+func TestSplitter_Split_CommentPolicies(t *testing.T) {
+	s, err := NewSplitter(';', DoubleQuotes, HashLineComments, BlockComments)
+	require.NoError(t, err)
+
+	// default is strip...
+	parts, err := s.Split(`x; /* a;b */ y # z`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`x`, `  y `}, parts)
+
+	s.SetCommentPolicy(CommentsKeep)
+	parts, err = s.Split(`x; /* a;b */ y # z`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`x`, ` /* a;b */ y # z`}, parts)
+	spc := &subPartsCapture{}
+	_, err = s.Split(`/* a;b */ y # z`, spc)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(spc.subParts))
+	subParts := spc.subParts[0]
+	require.Equal(t, 3, len(subParts))
+	require.Equal(t, Comment, subParts[0].Type())
+	require.True(t, subParts[0].IsComment())
+	require.False(t, subParts[0].IsQuote())
+	require.False(t, subParts[0].IsBrackets())
+	require.Equal(t, `/* a;b */`, subParts[0].String())
+	require.Equal(t, `/* a;b */`, subParts[0].UnEscaped())
+	require.Equal(t, Fixed, subParts[1].Type())
+	require.Equal(t, Comment, subParts[2].Type())
+	require.Equal(t, `# z`, subParts[2].String())
+	require.Equal(t, 12, subParts[2].StartPos())
+	require.Equal(t, 14, subParts[2].EndPos())
+
+	s.SetCommentPolicy(CommentsStrip)
+	spc = &subPartsCapture{}
+	parts, err = s.Split(`/* a;b */ y # z`, spc)
+	require.NoError(t, err)
+	require.Equal(t, []string{` y `}, parts)
+	require.Equal(t, 1, len(spc.subParts[0]))
+	require.Equal(t, Fixed, spc.subParts[0][0].Type())
+
+	s.SetCommentPolicy(CommentsError)
+	_, err = s.Split(`x; /* a;b */ y`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(commentFmt, 3), err.Error())
+	sErr, ok := err.(SplittingError)
+	require.True(t, ok)
+	require.Equal(t, CommentFound, sErr.Type())
+	require.True(t, sErr.Enclosure().IsComment)
+	_, err = s.Split(`x; "/* a;b */" y`)
+	require.NoError(t, err)
+}
+
+func TestSplitter_Split_LineComments(t *testing.T) {
+	s, err := NewSplitter('\n', DoubleQuotes, HashLineComments, DoubleSlashLineComments, DoubleDashLineComments, HtmlComments, BlockComments)
+	require.NoError(t, err)
+
+	parts, err := s.Split("a # one\r\nb // two\nc -- three\n<!-- four\n -->d /* five */", IgnoreEmpties, Trim(" \r"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a", "b", "c", "d"}, parts)
+
+	parts, err = s.Split("# comment\n\"a # b\"\nc", StripQuotes)
+	require.NoError(t, err)
+	require.Equal(t, []string{``, `a # b`, `c`}, parts)
+}
+
+func TestSplitter_Split_PrefixedEnclosures(t *testing.T) {
+	s, err := NewSplitter(',', SingleQuotesDoubleEscaped, EscapePrefixedSingleQuotes)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str    string
+		expect []string
+	}{
+		{`'it''s,ok', E'it\'s,ok'`, []string{`it's,ok`, `it's,ok`}},
+		{`e'a\'b,c', x`, []string{`a'b,c`, `x`}},
+		{`E'a\\', 'b'`, []string{`a\\`, `b`}},
+		{`SOME'a'',b'`, []string{`SOMEa',b`}},
+		{`E, 'E'`, []string{`E`, `E`}},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			parts, err := s.Split(tc.str, UnescapeQuotes, TrimSpaces)
+			require.NoError(t, err)
+			require.Equal(t, tc.expect, parts)
+		})
+	}
+
+	spc := &subPartsCapture{}
+	parts, err := s.Split(`e'a\'b' 'c''d'`, spc, StripQuotes)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a\'b c''d`}, parts)
+	subParts := spc.subParts[0]
+	require.Equal(t, 3, len(subParts))
+	require.Equal(t, `e`, subParts[0].Prefix())
+	require.Equal(t, "E", subParts[0].Enclosure().Prefix)
+	require.Equal(t, '\\', subParts[0].EscapeRune())
+	require.Equal(t, '\'', subParts[0].StartRune())
+	require.Equal(t, `a'b`, subParts[0].UnEscaped())
+	require.Equal(t, ``, subParts[1].Prefix())
+	require.Equal(t, ``, subParts[2].Prefix())
+	require.Equal(t, '\'', subParts[2].EscapeRune())
+	require.Equal(t, `c'd`, subParts[2].UnEscaped())
+
+	_, err = s.Split(`a, E'b`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `'`, 3), err.Error())
+
+	ps, err := NewSplitter(' ', DoubleQuotesBackSlashEscaped, RawPrefixedDoubleQuotes, BytesPrefixedDoubleQuotes, RawStrings)
+	require.NoError(t, err)
+	parts, err = ps.Split(`"a\" b" r"c\" B"d\" e" r#"f" g"#`, UnescapeQuotes)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a" b`, `c\`, `d" e`, `f" g`}, parts)
+
+	_, err = NewSplitter(',', EscapePrefixedSingleQuotes, &Enclosure{Start: '\'', End: '\'', IsQuote: true, Prefix: "e"})
+	require.Error(t, err)
+	require.Equal(t, `existing start encloser ('e'' in Enclosure[2])`, err.Error())
+	_, err = NewSplitter(',', SingleQuotes, SingleQuotesDoubleEscaped)
+	require.Error(t, err)
+}
+
+func TestSplitter_Split_NestingRules(t *testing.T) {
+	// regex character classes are opaque...
+	charClass := &Enclosure{Start: '[', End: ']', Opaque: true, Escapable: true, Escape: '\\'}
+	s, err := NewSplitter('|', charClass, Parenthesis)
+	require.NoError(t, err)
+	parts, err := s.Split(`a|[|(]|([\]|)])|b`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `[|(]`, `([\]|)])`, `b`}, parts)
+	spc := &subPartsCapture{}
+	_, err = s.Split(`[(]`, spc)
+	require.NoError(t, err)
+	require.Equal(t, Brackets, spc.subParts[0][0].Type())
+	require.True(t, spc.subParts[0][0].IsBrackets())
+	require.Nil(t, spc.subParts[0][0].Children())
+	_, err = s.Split(`a|[b`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, "[", 2), err.Error())
+
+	// curly brackets may only contain quotes...
+	curly := &Enclosure{Start: '{', End: '}', AllowedChildren: []*Enclosure{DoubleQuotes}}
+	s, err = NewSplitter(',', curly, DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+	parts, err = s.Split(`{a,"b,(c"},(d,{e})`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`{a,"b,(c"}`, `(d,{e})`}, parts)
+	_, err = s.Split(`a,{b,(c)}`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(nestingFmt, "(", 5), err.Error())
+	sErr, ok := err.(SplittingError)
+	require.True(t, ok)
+	require.Equal(t, NestingViolation, sErr.Type())
+	require.Equal(t, '(', sErr.Enclosure().Start)
+	_, err = s.Split(`{{}}`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(nestingFmt, "{", 1), err.Error())
+
+	// single quotes are tracked within double quotes...
+	dq := &Enclosure{Start: '"', End: '"', IsQuote: true, AllowedChildren: []*Enclosure{SingleQuotes}}
+	s, err = NewSplitter(',', dq, SingleQuotes, Parenthesis)
+	require.NoError(t, err)
+	parts, err = s.Split(`"a,'b,"c',(d",e`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`"a,'b,"c',(d"`, `e`}, parts)
+	spc = &subPartsCapture{}
+	_, err = s.Split(`"a'b"c'd"`, spc)
+	require.NoError(t, err)
+	subParts := spc.subParts[0]
+	require.Equal(t, 1, len(subParts))
+	require.Equal(t, Quotes, subParts[0].Type())
+	children := subParts[0].Children()
+	require.Equal(t, 3, len(children))
+	require.Equal(t, `a`, children[0].String())
+	require.Equal(t, `'b"c'`, children[1].String())
+	require.Equal(t, `d`, children[2].String())
+	_, err = s.Split(`"a'b"`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, "'", 2), err.Error())
+
+	// maximum depth...
+	maxParens := &Enclosure{Start: '(', End: ')', MaxDepth: 2}
+	s, err = NewSplitter(',', maxParens, SquareBrackets)
+	require.NoError(t, err)
+	parts, err = s.Split(`(a,(b)),[(c)]`)
+	require.NoError(t, err)
+	require.Equal(t, []string{`(a,(b))`, `[(c)]`}, parts)
+	_, err = s.Split(`(a,((b)))`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(nestingFmt, "(", 4), err.Error())
+	_, err = s.Split(`[[(a)]]`)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(nestingFmt, "(", 2), err.Error())
+
+	// violations are also reported by Validate...
+	s, err = NewSplitter(',', curly, DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+	errs := s.Validate(`{(a)},{"b"},{(c`)
+	msgs := make([]string, len(errs))
+	for i, e := range errs {
+		msgs[i] = e.Error()
+	}
+	require.Equal(t, []string{
+		fmt.Sprintf(nestingFmt, "(", 1),
+		fmt.Sprintf(unclosedFmt, "{", 12),
+		fmt.Sprintf(nestingFmt, "(", 13),
+		fmt.Sprintf(unclosedFmt, "(", 13),
+	}, msgs)
+}
@@ -0,0 +1,181 @@
+package splitter
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"unicode/utf8"
+)
+
+// NewSplitFunc returns a bufio.SplitFunc that splits the scanned input using the supplied Splitter
+//
+// The returned split func can be used with a bufio.Scanner - each token scanned is a part (with any options applied).
+// Enclosures may span buffer boundaries - a part is only returned once its terminating separator (and a little of the
+// input following it) has been read, so the maximum token size of the scanner must be large enough to hold the largest part
+// (the input is scanned incrementally - each read is scanned only once, regardless of how many reads a part spans)
+//
+// Positions reported by errors (and passed to options) are relative to the start of the input - the `totalLen` passed
+// to options being the length of the input read so far
+func NewSplitFunc(s Splitter, options ...Option) bufio.SplitFunc {
+	sp, ok := s.(*splitter)
+	if !ok || sp == nil {
+		return func(data []byte, atEOF bool) (int, []byte, error) {
+			return 0, nil, errors.New("invalid splitter")
+		}
+	}
+	st := &scanState{
+		splitter:  sp,
+		options:   sp.mergeOptions(options),
+		lookahead: sp.lookahead(),
+	}
+	return st.scan
+}
+
+// Reader reads parts, split by a Splitter, from an io.Reader
+//
+// Parts are read incrementally - only the part currently being read is held in memory
+type Reader struct {
+	scanner *bufio.Scanner
+}
+
+// NewReader creates a new Reader that reads parts from the supplied io.Reader split by the supplied Splitter
+func NewReader(s Splitter, r io.Reader, options ...Option) *Reader {
+	scanner := bufio.NewScanner(r)
+	scanner.Split(NewSplitFunc(s, options...))
+	return &Reader{
+		scanner: scanner,
+	}
+}
+
+// Buffer sets the initial buffer to use when reading and the maximum size of buffer that may be allocated
+// (the maximum buffer size limits the size of a part) - see bufio.Scanner.Buffer
+//
+// Buffer panics if it is called after reading has started
+func (r *Reader) Buffer(buf []byte, max int) {
+	r.scanner.Buffer(buf, max)
+}
+
+// Next returns the next part read
+//
+// Returns io.EOF when there are no more parts
+func (r *Reader) Next() (string, error) {
+	if r.scanner.Scan() {
+		return r.scanner.Text(), nil
+	} else if err := r.scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", io.EOF
+}
+
+// scanState is the state carried between calls to the split func
+type scanState struct {
+	splitter   *splitter
+	options    []Option
+	lookahead  int
+	ctx        *splitterContext
+	count      int
+	skipped    int
+	hereDocs   []pendingHereDoc
+	offset     int
+	runeOffset int
+	read       int
+	readRunes  int
+	token      []byte
+	final      bool
+}
+
+func (st *scanState) scan(data []byte, atEOF bool) (advance int, token []byte, err error) {
+	if !atEOF {
+		data = data[:completeRunes(data)]
+	}
+	ctx := st.context()
+	// the data always starts at the start of the pending part (so positions already scanned remain valid) - only the
+	// newly read data is scanned (and counted)...
+	ctx.str, ctx.len = bytesString(data), len(data)
+	ctx.partial = !atEOF
+	ctx.rxMatches = nil
+	if newFrom := st.read - st.offset; newFrom < len(data) {
+		st.readRunes += utf8.RuneCount(data[newFrom:])
+		st.read = st.offset + len(data)
+	}
+	ctx.runeLen = st.readRunes
+	st.token, st.final = nil, false
+	if _, err = ctx.split(); err != nil {
+		return 0, nil, err
+	} else if !ctx.stopped && !atEOF {
+		// more data needed...
+		return 0, nil, nil
+	}
+	st.count, st.skipped, st.hereDocs = ctx.count, ctx.skipped, ctx.hereDocs
+	st.offset, st.runeOffset = st.offset+ctx.lastAt, ctx.runePos(ctx.lastAt)
+	advance = ctx.lastAt
+	// the next part is scanned with a new context (as the data will then start at that part)...
+	st.ctx = nil
+	if atEOF && (st.final || !ctx.stopped) {
+		return advance, st.token, bufio.ErrFinalToken
+	}
+	return advance, st.token, nil
+}
+
+// context returns the splitter context for the pending part
+func (st *scanState) context() *splitterContext {
+	if st.ctx == nil {
+		st.ctx = newLazySplitterContext("", 0, 0, st.splitter, st.options)
+		st.ctx.runeCounter = counter{count: st.runeOffset}
+		st.ctx.lookahead = st.lookahead
+		st.ctx.count, st.ctx.skipped, st.ctx.hereDocs = st.count, st.skipped, st.hereDocs
+		st.ctx.yield = func(part Part) bool {
+			st.token = append([]byte{}, part.Value...)
+			st.final = part.Separator == ""
+			return false
+		}
+	}
+	return st.ctx
+}
+
+// lookahead returns the number of bytes at the end of the data that are left unscanned when streaming (until more
+// data is read or the end of input is reached) - so that what is found near the end of the data (e.g. the start of
+// a multi-rune enclosure or a separator) is only determined once enough of what follows it has been read
+func (s *splitter) lookahead() int {
+	n := 0
+	for i := range s.enclosures {
+		enc := &s.enclosures[i]
+		// (the starts and ends of dynamic enclosures are not fixed lengths - so whether they are found is deferred
+		// until more input is read)...
+		l := enc.startLen()
+		if el := 2 * enc.endLen(); enc.Matcher == nil && el > l {
+			// a double escaped end is followed by another end...
+			l = el
+		}
+		if l > n {
+			n = l
+		}
+	}
+	switch sep := s.sep.(type) {
+	case stringSeparator:
+		if len(sep) > n {
+			n = len(sep)
+		}
+	case keywordSeparator:
+		for _, kw := range sep {
+			if l := len(string(kw)); l > n {
+				n = l
+			}
+		}
+	}
+	// allowing for the rune following...
+	return n + utf8.UTFMax
+}
+
+// completeRunes returns the length of data excluding any trailing incomplete rune
+func completeRunes(data []byte) int {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return i
+			}
+			break
+		}
+	}
+	return len(data)
+}
@@ -0,0 +1,212 @@
+package splitter
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func readAllParts(r *Reader) ([]string, error) {
+	result := make([]string, 0)
+	for {
+		pt, err := r.Next()
+		if err == io.EOF {
+			return result, nil
+		} else if err != nil {
+			return result, err
+		}
+		result = append(result, pt)
+	}
+}
+
+func TestReader(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotesDoubleEscaped, Parenthesis)
+	require.NoError(t, err)
+	ws, err := NewWhitespaceSplitter(DoubleQuotes)
+	require.NoError(t, err)
+	ss, err := NewStringSplitter("::", DoubleQuotes)
+	require.NoError(t, err)
+	ms, err := NewSplitter(',', TripleDoubleQuotes, DoubleQuotes, BlockComments)
+	require.NoError(t, err)
+	ks, err := NewKeywordSplitter([]string{"AND"}, DoubleQuotes)
+	require.NoError(t, err)
+	hs, err := NewSplitter(';', HereDocs, DoubleQuotes)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		splitter Splitter
+		str      string
+		options  []Option
+	}{
+		{s, ``, nil},
+		{s, `,`, nil},
+		{s, `a`, nil},
+		{s, `a,b,c`, nil},
+		{s, `a,b,`, nil},
+		{s, `,a,b`, nil},
+		{s, `a,"b,""c""\n,d",(e,(f,g)),h`, nil},
+		{s, "a,\"b\n,c\",d\n", nil},
+		{s, `日本,"語,日",本`, nil},
+		{s, `,a,,b,`, []Option{IgnoreEmpties}},
+		{s, `,a,,b,`, []Option{IgnoreEmptyOuters}},
+		{s, ` a , b ,c `, []Option{TrimSpaces}},
+		{ws, `  a  "b  c"   d  `, nil},
+		{ws, ``, nil},
+		{ws, `   `, nil},
+		{ss, `a::b:c::"d::e"::`, nil},
+		{ms, `a,"""b,"c","""/* d, */,"e,f",g`, nil},
+		{ms, `a,/* b, */c,""",""",d`, nil},
+		{ks, `a AND b ANDc "AND" AND d`, nil},
+		{hs, "cat <<EOF; echo x\nbody;\nEOF\n; ls", nil},
+		{hs, "cat <<-A <<B; x\n\ta;\n\tA\nb;\nB\n;y", nil},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			expect, err := tc.splitter.Split(tc.str, tc.options...)
+			require.NoError(t, err)
+
+			r := NewReader(tc.splitter, strings.NewReader(tc.str), tc.options...)
+			parts, err := readAllParts(r)
+			require.NoError(t, err)
+			require.Equal(t, expect, parts)
+
+			r = NewReader(tc.splitter, iotest.OneByteReader(strings.NewReader(tc.str)), tc.options...)
+			parts, err = readAllParts(r)
+			require.NoError(t, err)
+			require.Equal(t, expect, parts)
+		})
+	}
+}
+
+func TestReader_Errors(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis)
+	require.NoError(t, err)
+
+	r := NewReader(s, iotest.OneByteReader(strings.NewReader(`a,b,"c`)))
+	parts, err := readAllParts(r)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unclosedFmt, `"`, 4), err.Error())
+	require.Equal(t, []string{`a`, `b`}, parts)
+
+	r = NewReader(s, iotest.OneByteReader(strings.NewReader(`a,b)`)))
+	parts, err = readAllParts(r)
+	require.Error(t, err)
+	require.Equal(t, fmt.Sprintf(unopenedFmt, `)`, 3), err.Error())
+	require.Equal(t, []string{`a`}, parts)
+
+	r = NewReader(s, strings.NewReader(`a,,b`), NoEmpties)
+	parts, err = readAllParts(r)
+	require.Error(t, err)
+	require.Equal(t, []string{`a`}, parts)
+}
+
+func TestReader_Buffer(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes)
+	require.NoError(t, err)
+
+	r := NewReader(s, strings.NewReader(`a,"`+strings.Repeat("b,", 32)+`",c`))
+	r.Buffer(make([]byte, 0, 16), 32)
+	parts, err := readAllParts(r)
+	require.Error(t, err)
+	require.Equal(t, bufio.ErrTooLong, err)
+	require.Equal(t, []string{`a`}, parts)
+
+	r = NewReader(s, strings.NewReader(`a,"`+strings.Repeat("b,", 32)+`",c`))
+	r.Buffer(make([]byte, 0, 16), 128)
+	parts, err = readAllParts(r)
+	require.NoError(t, err)
+	require.Equal(t, []string{`a`, `"` + strings.Repeat("b,", 32) + `"`, `c`}, parts)
+}
+
+func TestReader_LongDynamicEnclosures(t *testing.T) {
+	tag := strings.Repeat("t", 40)
+	testCases := []struct {
+		splitter Splitter
+		str      string
+	}{
+		{MustCreateSplitter(';', DollarQuotes), "a;$" + tag + "$b;c$" + tag + "$;d"},
+		{MustCreateSplitter(';', RawStrings), "a;r" + strings.Repeat("#", 40) + `"b;c"` + strings.Repeat("#", 40) + ";d"},
+		{MustCreateSplitter(';', BacktickFences), "a;" + strings.Repeat("`", 40) + "b;c" + strings.Repeat("`", 40) + ";d"},
+		{MustCreateSplitter(';', HereDocs), "a;cat <<" + tag + "\nb;c\n" + tag + "\n;d"},
+		{MustCreateSplitter(';', HereDocs), "a;cat <<-" + tag + "\nb;c\n" + strings.Repeat("\t", 40) + tag + "\n;d"},
+	}
+	for i, tc := range testCases {
+		// the dynamic start and end straddle the end of the initial buffer at differing offsets...
+		for offset := 4096 - 100; offset < 4096+10; offset++ {
+			str := strings.Repeat("x", offset) + tc.str
+			expect, err := tc.splitter.Split(str)
+			require.NoError(t, err)
+			require.Equal(t, 3, len(expect))
+			r := NewReader(tc.splitter, strings.NewReader(str))
+			parts, err := readAllParts(r)
+			require.NoError(t, err, "[%d] offset %d", i+1, offset)
+			require.Equal(t, expect, parts, "[%d] offset %d", i+1, offset)
+			r = NewReader(tc.splitter, strings.NewReader(tc.str+str))
+			r.Buffer(make([]byte, 0, offset%64+1), 1<<16)
+			parts, err = readAllParts(r)
+			require.NoError(t, err, "[%d] offset %d", i+1, offset)
+			require.Equal(t, 5, len(parts), "[%d] offset %d", i+1, offset)
+		}
+	}
+}
+
+func TestReader_OptionPositions(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes)
+	require.NoError(t, err)
+	str := `日本,"語,日",,本`
+	expect := &positionsCapture{}
+	_, err = s.Split(str, expect)
+	require.NoError(t, err)
+
+	actual := &positionsCapture{}
+	r := NewReader(s, iotest.OneByteReader(strings.NewReader(str)), actual)
+	_, err = readAllParts(r)
+	require.NoError(t, err)
+	require.Equal(t, expect.positions, actual.positions)
+	require.Equal(t, []int{0, 3, 9, 10}, actual.positions)
+	require.Equal(t, 11, actual.totalLen)
+}
+
+type positionsCapture struct {
+	positions []int
+	totalLen  int
+}
+
+func (o *positionsCapture) Apply(s string, pos int, totalLen int, captured int, skipped int, isLast bool, subParts ...SubPart) (string, bool, error) {
+	o.positions = append(o.positions, pos)
+	o.totalLen = totalLen
+	return s, true, nil
+}
+
+func TestNewSplitFunc(t *testing.T) {
+	s, err := NewSplitter('|', DoubleQuotes)
+	require.NoError(t, err)
+
+	scanner := bufio.NewScanner(strings.NewReader("a|\"b|\nc\"|d"))
+	scanner.Split(NewSplitFunc(s, StripQuotes))
+	parts := make([]string, 0)
+	for scanner.Scan() {
+		parts = append(parts, scanner.Text())
+	}
+	require.NoError(t, scanner.Err())
+	require.Equal(t, []string{`a`, "b|\nc", `d`}, parts)
+
+	scanner = bufio.NewScanner(strings.NewReader(`a|b`))
+	scanner.Split(NewSplitFunc(nil))
+	require.False(t, scanner.Scan())
+	require.Error(t, scanner.Err())
+}
+
+func TestCompleteRunes(t *testing.T) {
+	b := []byte("a日")
+	require.Equal(t, 4, completeRunes(b))
+	require.Equal(t, 1, completeRunes(b[:3]))
+	require.Equal(t, 1, completeRunes(b[:2]))
+	require.Equal(t, 1, completeRunes(b[:1]))
+	require.Equal(t, 0, completeRunes(b[:0]))
+	require.Equal(t, 2, completeRunes([]byte{'a', 0xff}))
+}
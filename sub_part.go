@@ -1,6 +1,8 @@
 package splitter
 
-import "strings"
+import (
+	"strings"
+)
 
 // SubPartType denotes the type of the SubPart (as returned from SubPart.Type)
 type SubPartType int
@@ -9,6 +11,7 @@ const (
 	Fixed SubPartType = iota
 	Quotes
 	Brackets
+	Comment
 )
 
 // SubPart is the interfaces passed to Options.Apply - to enable examination of sub-parts found in a split part
@@ -23,7 +26,9 @@ type SubPart interface {
 	IsBrackets() bool
 	// IsFixed returns whether the part was fixed text (i.e not quotes or brackets)
 	IsFixed() bool
-	// Type returns the part type (fixed, quotes, brackets)
+	// IsComment returns whether the part is a comment enclosure
+	IsComment() bool
+	// Type returns the part type (fixed, quotes, brackets, comment)
 	Type() SubPartType
 	// Escapable returns whether the original enclosure was escapable
 	Escapable() bool
@@ -33,43 +38,65 @@ type SubPart interface {
 	EndRune() rune
 	// EscapeRune returns the original escape rune for the enclosure
 	EscapeRune() rune
+	// Prefix returns the prefix (as found in the string) that selected the variant of the enclosure (see Enclosure.Prefix) -
+	// or an empty string if the enclosure has no prefix
+	Prefix() string
 	// UnEscaped returns the unescaped string
 	//
 	// If the part was a quote enclosure, the enclosing quote marks are stripped and, if escapable, any escaped quotes are transposed.
 	// If the quote enclosure was not escapable, just the enclosing quote marks are removed
 	//
-	// If the part was not a quote enclosure, the original string part is returned
+	// If the part was fixed text and the splitter has a separator escape (see Splitter.SetSeparatorEscape), the escape runes
+	// for any escaped separators are removed
+	//
+	// Otherwise, the original string part is returned
 	UnEscaped() string
 	// String returns the actual raw string of the part
 	String() string
 	// IsWhitespaceOnly returns whether the item is whitespace only (using the given trim cutset)
 	IsWhitespaceOnly(cutset ...string) bool
+	// Children returns the sub-parts nested within a brackets enclosure (i.e. fixed text, quotes and nested brackets found within the brackets)
+	//
+	// If the part is fixed text or an opaque enclosure (e.g. quotes) without allowed children (see Enclosure.AllowedChildren),
+	// nil is returned (as these cannot have nested parts)
+	Children() []SubPart
 
 	Enclosure() *Enclosure
 }
 
+// fixedEnclosure is the (empty) enclosure of fixed text sub-parts
+var fixedEnclosure = &Enclosure{}
+
+// subPart is a sub-part found in a split part - the start and end being byte positions within the string
+// (the end being exclusive)
 type subPart struct {
-	enc      Enclosure
-	openPos  int
-	closePos int
+	enc      *Enclosure
+	start    int
+	end      int
+	startLen int
 	ctx      *splitterContext
 	fixed    bool
+	children []SubPart
 }
 
 func (s *subPart) StartPos() int {
-	return s.openPos
+	return s.ctx.runePos(s.start)
 }
 
 func (s *subPart) EndPos() int {
-	return s.closePos
+	return s.ctx.runePos(s.end) - 1
 }
 
 func (s *subPart) IsQuote() bool {
-	return s.enc.IsQuote
+	return s.enc.IsQuote && !s.enc.IsComment
 }
 
 func (s *subPart) IsBrackets() bool {
-	return !s.fixed && !s.enc.IsQuote
+	return !s.fixed && !s.enc.IsQuote && !s.enc.IsComment
+}
+
+func (s *subPart) IsComment() bool {
+	return s.enc.IsComment
 }
 
 func (s *subPart) IsFixed() bool {
@@ -79,6 +106,8 @@ func (s *subPart) IsFixed() bool {
 func (s *subPart) Type() SubPartType {
 	if s.fixed {
 		return Fixed
+	} else if s.enc.IsComment {
+		return Comment
 	} else if !s.enc.IsQuote {
 		return Brackets
 	}
@@ -90,28 +119,43 @@ func (s *subPart) Escapable() bool {
 }
 
 func (s *subPart) StartRune() rune {
-	return s.enc.Start
+	return s.enc.startRune()
 }
 
 func (s *subPart) EndRune() rune {
-	return s.enc.End
+	return s.enc.endRune()
 }
 
 func (s *subPart) EscapeRune() rune {
 	return s.enc.Escape
 }
 
+func (s *subPart) Prefix() string {
+	if s.fixed || s.enc.Prefix == "" {
+		return ""
+	}
+	return s.ctx.str[s.start : s.start+s.startLen-len(s.enc.startSeq())]
+}
+
 func (s *subPart) UnEscaped() string {
-	if s.fixed || !s.enc.IsQuote {
-		return string(s.ctx.runes[s.openPos : s.closePos+1])
-	} else if !s.enc.isEscapable() {
-		return string(s.ctx.runes[s.openPos+1 : s.closePos])
+	if s.fixed && len(s.ctx.escapes) > 0 {
+		return s.ctx.unescaped(s.start, s.end)
+	} else if s.fixed || !s.IsQuote() {
+		return s.String()
 	}
-	return strings.ReplaceAll(string(s.ctx.runes[s.openPos+1:s.closePos]), string([]rune{s.enc.Escape, s.enc.End}), string(s.enc.End))
+	inner := s.ctx.str[s.start+s.startLen : s.end-s.enc.endLen()]
+	if !s.enc.isEscapable() {
+		return inner
+	}
+	end := s.enc.endSeq()
+	if s.enc.isDoubleEscaping() {
+		return strings.ReplaceAll(inner, end+end, end)
+	}
+	return strings.ReplaceAll(inner, string(s.enc.Escape)+end, end)
 }
 
 func (s *subPart) String() string {
-	return string(s.ctx.runes[s.openPos : s.closePos+1])
+	return s.ctx.str[s.start:s.end]
 }
 
 func (s *subPart) IsWhitespaceOnly(cutset ...string) bool {
@@ -122,9 +166,30 @@ func (s *subPart) IsWhitespaceOnly(cutset ...string) bool {
 	if len(cutset) > 0 {
 		cuts = strings.Join(cutset, "")
 	}
-	return strings.Trim(string(s.ctx.runes[s.openPos:s.closePos+1]), cuts) == ""
+	return strings.Trim(s.String(), cuts) == ""
 }
 
 func (s *subPart) Enclosure() *Enclosure {
-	return &s.enc
+	return s.enc.copy()
+}
+
+func (s *subPart) Children() []SubPart {
+	return s.children
+}
+
+// purgeFixed adds any fixed text (up to the position) as a child
+func (s *subPart) purgeFixed(pos int) {
+	last := s.start + s.startLen
+	if l := len(s.children); l > 0 {
+		last = s.children[l-1].(*subPart).end
+	}
+	if last < pos {
+		s.children = append(s.children, &subPart{
+			enc:   fixedEnclosure,
+			start: last,
+			end:   pos,
+			ctx:   s.ctx,
+			fixed: true,
+		})
+	}
 }
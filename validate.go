@@ -0,0 +1,114 @@
+package splitter
+
+import (
+	"sort"
+	"unicode/utf8"
+)
+
+func (s *splitter) Validate(str string) []SplittingError {
+	return newLazySplitterContext(str, 0, len(str), s, nil).validate()
+}
+
+// validate walks the string tracking enclosures (without capturing any parts) - collecting every balance problem
+func (ctx *splitterContext) validate() []SplittingError {
+	result := make([]SplittingError, 0)
+	for ctx.pos = ctx.start; ctx.pos < ctx.len; ctx.pos += ctx.size {
+		ctx.rune, ctx.size = utf8.DecodeRuneInString(ctx.str[ctx.pos:ctx.len])
+		ctx.endLineComment()
+		if isEnd, n, inQuote := ctx.isQuoteEnd(); n > 0 {
+			if isEnd {
+				ctx.close()
+			}
+			ctx.size = n
+		} else {
+			isClose, n, skipClose := false, 0, false
+			if !inQuote {
+				isClose, n, skipClose = ctx.isClose()
+			}
+			if isClose && !skipClose {
+				ctx.close()
+				ctx.size = n
+			} else if enc, on := ctx.isOpener(); enc != nil {
+				if err := ctx.checkNesting(enc); err != nil {
+					result = append(result, err)
+				}
+				ctx.open(enc, on)
+				ctx.size = on
+			} else if on > 0 {
+				ctx.size = on
+			} else if n > 0 && !skipClose {
+				cEnc, _ := ctx.closerAt()
+				if ctx.isOpen(cEnc) {
+					// the current enclosure is closed by the wrong closer...
+					result = append(result, newSplittingError(Mismatched, ctx.runePos(ctx.pos), ctx.rune, ctx.current.enc.copy()))
+					ctx.close()
+					// any further enclosures (opened after the one this closer closes) are unclosed...
+					for ctx.current.enc.endSeq() != cEnc.endSeq() {
+						result = append(result, ctx.unclosedError())
+						ctx.close()
+					}
+					ctx.close()
+				} else {
+					result = append(result, newSplittingError(Unopened, ctx.runePos(ctx.pos), ctx.rune, cEnc.copy()))
+				}
+				ctx.size = n
+			}
+		}
+	}
+	ctx.endLineComment()
+	for ctx.inAny() {
+		result = append(result, ctx.unclosedError())
+		ctx.close()
+	}
+	for _, hd := range ctx.hereDocs {
+		result = append(result, hd.unclosedError())
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Position() < result[j].Position()
+	})
+	return result
+}
+
+// unclosedError returns an Unclosed error for the current enclosure
+func (ctx *splitterContext) unclosedError() SplittingError {
+	return newSplittingError(Unclosed, ctx.runePos(ctx.current.start), ctx.current.enc.startRune(), ctx.current.enc.copy())
+}
+
+// unclosedError returns an Unclosed error for a heredoc whose body never started
+func (hd pendingHereDoc) unclosedError() SplittingError {
+	return newSplittingError(Unclosed, hd.runePos, '<', hd.enc.copy())
+}
+
+// isOpen determines whether an enclosure with the same end as the supplied enclosure is currently open
+func (ctx *splitterContext) isOpen(enc *Enclosure) bool {
+	end := enc.endSeq()
+	for _, sp := range ctx.stack {
+		if sp.enc.endSeq() == end {
+			return true
+		}
+	}
+	return ctx.current != nil && ctx.current.enc.endSeq() == end
+}
+
+// open is a lightweight push - that does not track sub-parts
+func (ctx *splitterContext) open(enc *Enclosure, n int) {
+	if ctx.current != nil {
+		ctx.stack = append(ctx.stack, ctx.current)
+	}
+	ctx.current = &subPart{
+		start:    ctx.pos,
+		startLen: n,
+		enc:      enc,
+		ctx:      ctx,
+	}
+}
+
+// close is a lightweight pop - that does not track sub-parts
+func (ctx *splitterContext) close() {
+	if l := len(ctx.stack); l > 0 {
+		ctx.current = ctx.stack[l-1]
+		ctx.stack = ctx.stack[0 : l-1]
+	} else {
+		ctx.current = nil
+	}
+}
@@ -0,0 +1,95 @@
+package splitter
+
+import (
+	"fmt"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func TestSplitter_Validate(t *testing.T) {
+	s, err := NewSplitter(',', DoubleQuotes, Parenthesis, SquareBrackets, CurlyBrackets)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		str    string
+		expect []string
+	}{
+		{``, []string{}},
+		{`a,"b,(c",(d,[e]),{f}`, []string{}},
+		{`a)`, []string{fmt.Sprintf(unopenedFmt, ")", 1)}},
+		{`a)b]c}`, []string{
+			fmt.Sprintf(unopenedFmt, ")", 1),
+			fmt.Sprintf(unopenedFmt, "]", 3),
+			fmt.Sprintf(unopenedFmt, "}", 5),
+		}},
+		{`(a`, []string{fmt.Sprintf(unclosedFmt, "(", 0)}},
+		{`([{"a`, []string{
+			fmt.Sprintf(unclosedFmt, "(", 0),
+			fmt.Sprintf(unclosedFmt, "[", 1),
+			fmt.Sprintf(unclosedFmt, "{", 2),
+			fmt.Sprintf(unclosedFmt, `"`, 3),
+		}},
+		{`(a]`, []string{
+			fmt.Sprintf(unclosedFmt, "(", 0),
+			fmt.Sprintf(unopenedFmt, "]", 2),
+		}},
+		{`[(a]`, []string{
+			fmt.Sprintf(mismatchedFmt, "]", 3),
+		}},
+		{`[({a]),b)`, []string{
+			fmt.Sprintf(unclosedFmt, "(", 1),
+			fmt.Sprintf(mismatchedFmt, "]", 4),
+			fmt.Sprintf(unopenedFmt, ")", 5),
+			fmt.Sprintf(unopenedFmt, ")", 8),
+		}},
+		{`"a)"),(b`, []string{
+			fmt.Sprintf(unopenedFmt, ")", 4),
+			fmt.Sprintf(unclosedFmt, "(", 6),
+		}},
+	}
+	for i, tc := range testCases {
+		t.Run(fmt.Sprintf("[%d]%s", i+1, tc.str), func(t *testing.T) {
+			errs := s.Validate(tc.str)
+			msgs := make([]string, len(errs))
+			for ei, e := range errs {
+				msgs[ei] = e.Error()
+			}
+			require.Equal(t, tc.expect, msgs)
+		})
+	}
+
+	errs := s.Validate(`[(a]`)
+	require.Equal(t, 1, len(errs))
+	require.Equal(t, Mismatched, errs[0].Type())
+	require.Equal(t, 3, errs[0].Position())
+	require.Equal(t, ']', errs[0].Rune())
+	require.Equal(t, '(', errs[0].Enclosure().Start)
+
+	ms, err := NewSplitter(',', TripleDoubleQuotes, DoubleCurlyBrackets, SquareBrackets)
+	require.NoError(t, err)
+	errs = ms.Validate(`[{{a]},"""b""`)
+	msgs := make([]string, len(errs))
+	for ei, e := range errs {
+		msgs[ei] = e.Error()
+	}
+	require.Equal(t, []string{
+		fmt.Sprintf(mismatchedFmt, "]", 4),
+		fmt.Sprintf(unclosedFmt, `"`, 7),
+	}, msgs)
+
+	cs, err := NewSplitter(',', DoubleQuotes, Parenthesis, HashLineComments, BlockComments)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(cs.Validate("(a, /* ) */ b) # (")))
+	errs = cs.Validate("(a # )\n/* b")
+	require.Equal(t, 2, len(errs))
+	require.Equal(t, fmt.Sprintf(unclosedFmt, "(", 0), errs[0].Error())
+	require.Equal(t, fmt.Sprintf(unclosedFmt, "/", 7), errs[1].Error())
+
+	// the first problem is the same as Split reports...
+	for _, str := range []string{`a)b]`, `a,(b`, `"a`} {
+		_, err := s.Split(str)
+		require.Error(t, err)
+		errs = s.Validate(str)
+		require.Equal(t, err.Error(), errs[0].Error())
+	}
+}